@@ -0,0 +1,383 @@
+package main
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"errors"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"reflect"
+	"sort"
+
+	"github.com/delendum-xyz/private-voting/fdkg/common"
+	"github.com/delendum-xyz/private-voting/fdkg/election"
+	"github.com/delendum-xyz/private-voting/fdkg/pki"
+	"github.com/delendum-xyz/private-voting/fdkg/sss"
+	"github.com/delendum-xyz/private-voting/fdkg/utils"
+	"github.com/samber/lo"
+)
+
+// Behaviour is what an adversarial party does instead of following the
+// protocol.
+type Behaviour int
+
+const (
+	Honest Behaviour = iota
+	// InconsistentShares deals one guardian a share that does not match the
+	// dealer's commitments.
+	InconsistentShares
+	// WrongPartialDecryption publishes partial decryptions, as a tallier and
+	// as a guardian, that differ from the value its proof is for.
+	WrongPartialDecryption
+	// InvalidBallot posts a ballot that is not a pair of curve points.
+	InvalidBallot
+	// Withholding takes part in the DKG but publishes nothing in the tally
+	// phase, neither its own partial decryption nor any as a guardian.
+	Withholding
+	// Equivocation posts two conflicting versions of its contribution and of
+	// its ballot.
+	Equivocation
+)
+
+var behaviourNames = []string{"honest", "inconsistent-shares", "wrong-partial-decryption", "invalid-ballot", "withholding", "equivocation"}
+
+func (b Behaviour) String() string {
+	if b < 0 || int(b) >= len(behaviourNames) {
+		return fmt.Sprintf("behaviour(%d)", int(b))
+	}
+	return behaviourNames[b]
+}
+
+// Behaviours lists every adversarial behaviour.
+var Behaviours = []Behaviour{InconsistentShares, WrongPartialDecryption, InvalidBallot, Withholding, Equivocation}
+
+// Adversary maps the index of every corrupted party to its behaviour.
+// Parties not in the map are honest.
+type Adversary map[int]Behaviour
+
+// Corrupt marks count parties, sampled from indices with r, as adversarial,
+// assigning the behaviours round-robin.
+func Corrupt(indices []int, count int, behaviours []Behaviour, r *rand.Rand) Adversary {
+	if count > len(indices) {
+		count = len(indices)
+	}
+	adversary := make(Adversary)
+	for i, j := range r.Perm(len(indices))[:count] {
+		adversary[indices[j]] = behaviours[i%len(behaviours)]
+	}
+	return adversary
+}
+
+const (
+	PhaseDistribution = "distribution"
+	PhaseVoting       = "voting"
+	PhaseTally        = "tally"
+)
+
+// Complaint is raised by an honest check of the protocol against the party it
+// holds responsible. Reason names the misbehaviour the check detects.
+type Complaint struct {
+	Against int
+	Reason  Behaviour
+	Phase   string
+}
+
+// Fault is the outcome for one adversarial party. Committed is false when the
+// behaviour never came into play, e.g. a WrongPartialDecryption guardian
+// whose partial decryption was not needed.
+type Fault struct {
+	Party     int
+	Behaviour Behaviour
+	Committed bool
+	// Detected is true if some complaint names the party, Attributed if one
+	// names it for this behaviour.
+	Detected   bool
+	Attributed bool
+}
+
+// ByzantineReport summarises an election run against an Adversary.
+type ByzantineReport struct {
+	Faults        []Fault
+	Complaints    []Complaint
+	Blamed        []int
+	FalselyBlamed []int
+	// Disqualified talliers are left out of the voting key.
+	Disqualified []int
+	Expected     []int
+	Tally        []int
+	TallyCorrect bool
+	// Err is set when the tally could not be computed.
+	Err error
+}
+
+var ErrNoQualifiedTalliers = errors.New("every tallier was disqualified")
+
+type byzantineRun struct {
+	adversary  Adversary
+	complaints []Complaint
+	committed  map[int]bool
+}
+
+func (b *byzantineRun) complain(against int, reason Behaviour, phase string) {
+	b.complaints = append(b.complaints, Complaint{Against: against, Reason: reason, Phase: phase})
+}
+
+func (b *byzantineRun) misbehaves(index int, behaviour Behaviour) bool {
+	if b.adversary[index] != behaviour {
+		return false
+	}
+	b.committed[index] = true
+	return true
+}
+
+// uniquePosts resolves what every author posted on the board. An author that
+// posted two different payloads in the same phase equivocated: it is blamed
+// and all of its posts are dropped.
+func uniquePosts[T any](b *byzantineRun, posts map[int][]T, encode func(T) []byte, phase string) map[int]T {
+	result := make(map[int]T)
+	for _, author := range sortedKeys(posts) {
+		versions := lo.UniqBy(posts[author], func(post T) string { return string(encode(post)) })
+		if len(versions) > 1 {
+			b.complain(author, Equivocation, phase)
+			continue
+		}
+		result[author] = versions[0]
+	}
+	return result
+}
+
+func sortedKeys[T any](m map[int]T) []int {
+	keys := lo.Keys(m)
+	sort.Ints(keys)
+	return keys
+}
+
+// encodePoints serializes points for comparing posts; unlike Marshal it also
+// accepts points off the curve.
+func encodePoints(buf *bytes.Buffer, points ...common.Point) {
+	for _, p := range points {
+		fmt.Fprintf(buf, "%x,%x;", &p.X, &p.Y)
+	}
+}
+
+func encodeContribution(c pki.DkgContribution) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%d:%v:", c.Threshold, c.Guardians)
+	encodePoints(&buf, c.VotingPublicKey)
+	encodePoints(&buf, c.Commitments...)
+	return buf.Bytes()
+}
+
+func encodeBallot(b common.EncryptedBallot) []byte {
+	var buf bytes.Buffer
+	encodePoints(&buf, b.C1, b.C2)
+	return buf.Bytes()
+}
+
+// corruptShare changes the first share dealer dealt, so it no longer matches
+// the dealer's commitments.
+func corruptShare(received election.PartyIndexToShares, dealer int, curve elliptic.Curve) {
+	for _, guardian := range sortedKeys(received) {
+		for i, share := range received[guardian] {
+			if share.From == dealer {
+				value := new(big.Int).Add(&share.Value, big.NewInt(1))
+				received[guardian][i].Value = *value.Mod(value, curve.Params().N)
+				return
+			}
+		}
+	}
+}
+
+func addGenerator(p common.Point, curve elliptic.Curve) common.Point {
+	return common.BigIntToPoint(curve.Add(&p.X, &p.Y, curve.Params().Gx, curve.Params().Gy))
+}
+
+// ExpectedTally counts the votes in the shape returned by election.Decrypt.
+func ExpectedTally(votes []int, options int) []int {
+	if options == 2 {
+		return []int{lo.Count(votes, 1)}
+	}
+	counts := make([]int, 4)
+	for _, vote := range votes {
+		counts[vote]++
+	}
+	return counts
+}
+
+// RunByzantineElection runs a full election in which the parties of the
+// adversary deviate from the protocol, and reports which faults the honest
+// checks caught and whether the tally still came out right.
+//
+// Guardians verify their shares against the dealer's Feldman commitments and
+// a failed check disqualifies the dealer, assuming the complaint itself can be
+// checked on the board. Ballots must be curve points, talliers prove their
+// partial decryptions with DLEQ, and a tallier that withholds or fails its
+// proof is replaced by its guardians' proven partial decryptions.
+func RunByzantineElection(config common.VotingConfig, n_dkg int, adversary Adversary, curve elliptic.Curve, r *rand.Rand) ByzantineReport {
+	run := &byzantineRun{adversary: adversary, committed: make(map[int]bool)}
+	localNodes, dkgNodes, err := pki.GenerateSetOfNodes(config, n_dkg, pki.UniformSelector{}, curve, r)
+	if err != nil {
+		return ByzantineReport{Err: err}
+	}
+	sort.Slice(dkgNodes, func(i, j int) bool { return dkgNodes[i].Index < dkgNodes[j].Index })
+
+	// distribution: publish contributions, deal and verify shares
+	posted := make(map[int][]pki.DkgContribution)
+	for _, node := range dkgNodes {
+		contribution := node.Contribution(curve)
+		posted[node.Index] = append(posted[node.Index], contribution)
+		if run.misbehaves(node.Index, Equivocation) {
+			other := contribution
+			key := utils.RandomBigInt(curve, r)
+			other.VotingPublicKey = common.BigIntToPoint(curve.ScalarBaseMult(key.Bytes()))
+			other.Commitments = append([]common.Point{other.VotingPublicKey}, contribution.Commitments[1:]...)
+			posted[node.Index] = append(posted[node.Index], other)
+		}
+	}
+	contributions := uniquePosts(run, posted, encodeContribution, PhaseDistribution)
+
+	published := lo.Filter(dkgNodes, func(node pki.DkgParty, _ int) bool {
+		_, ok := contributions[node.Index]
+		return ok
+	})
+	received := election.DistributeShares(published, curve)
+	for _, node := range published {
+		if run.misbehaves(node.Index, InconsistentShares) {
+			corruptShare(received, node.Index, curve)
+		}
+	}
+	var dealt []sss.Share
+	for _, guardian := range sortedKeys(received) {
+		dealt = append(dealt, received[guardian]...)
+	}
+	valid := utils.ParallelMap(dealt, func(share sss.Share) bool {
+		return contributions[share.From].VerifyShare(share, curve)
+	})
+	disqualified := make(map[int]bool)
+	for i, share := range dealt {
+		if !valid[i] && !disqualified[share.From] {
+			disqualified[share.From] = true
+			run.complain(share.From, InconsistentShares, PhaseDistribution)
+		}
+	}
+	qualified := lo.Filter(dkgNodes, func(node pki.DkgParty, _ int) bool {
+		_, published := contributions[node.Index]
+		return published && !disqualified[node.Index]
+	})
+
+	report := ByzantineReport{
+		Disqualified: lo.Without(lo.Map(dkgNodes, func(node pki.DkgParty, _ int) int { return node.Index }),
+			lo.Map(qualified, func(node pki.DkgParty, _ int) int { return node.Index })...),
+	}
+	if len(qualified) == 0 {
+		report.Err = ErrNoQualifiedTalliers
+		return run.finish(report)
+	}
+	encryptionKey := election.VotingPublicKey(qualified, curve)
+
+	// voting: every party posts a ballot, the board keeps well-formed ones
+	ballots := make(map[int][]common.EncryptedBallot)
+	for i, ballot := range election.Voting(localNodes, encryptionKey, curve, r) {
+		node := localNodes[i]
+		if run.misbehaves(node.Index, InvalidBallot) {
+			ballot.C1 = common.BigIntToPoint(big.NewInt(1), big.NewInt(1))
+		}
+		ballots[node.Index] = append(ballots[node.Index], ballot)
+		if run.misbehaves(node.Index, Equivocation) {
+			ballots[node.Index] = append(ballots[node.Index], election.Voting([]pki.LocalParty{node}, encryptionKey, curve, r)...)
+		}
+	}
+	unique := uniquePosts(run, ballots, encodeBallot, PhaseVoting)
+	var accepted []common.EncryptedBallot
+	var votes []int
+	for _, node := range localNodes {
+		ballot, ok := unique[node.Index]
+		if !ok {
+			continue
+		}
+		if !ballot.C1.IsOnCurve(curve) || !ballot.C2.IsOnCurve(curve) {
+			run.complain(node.Index, InvalidBallot, PhaseVoting)
+			continue
+		}
+		accepted = append(accepted, ballot)
+		votes = append(votes, node.Vote())
+	}
+	report.Expected = ExpectedTally(votes, config.Options)
+
+	// tally: proven partial decryptions, reconstructed from guardians if needed
+	accumulator := election.Accumulate(accepted, config, encryptionKey, curve)
+	C1 := accumulator.Sum().C1
+	publish := func(share pki.DecryptionShare) (pki.DecryptionShare, bool) {
+		if run.misbehaves(share.Index, Withholding) {
+			return pki.DecryptionShare{}, false
+		}
+		if run.misbehaves(share.Index, WrongPartialDecryption) {
+			share.Value = addGenerator(share.Value, curve)
+		}
+		return share, true
+	}
+
+	shares := make(map[int][]pki.DecryptionShare)
+	failed := make(map[int]bool)
+	for _, node := range qualified {
+		share, ok := publish(node.PartialDecryption(C1, curve))
+		if !ok {
+			run.complain(node.Index, Withholding, PhaseTally)
+		} else if !contributions[node.Index].VerifyPartialDecryption(share, C1, curve) {
+			run.complain(node.Index, WrongPartialDecryption, PhaseTally)
+		} else {
+			shares[node.Index] = []pki.DecryptionShare{share}
+			continue
+		}
+		failed[node.Index] = true
+	}
+	for _, guardian := range sortedKeys(received) {
+		decryptions := election.GuardianDecryptions(guardian, received, failed, C1, config.ElectionID(), curve)
+		for _, tallier := range sortedKeys(decryptions) {
+			share, ok := publish(decryptions[tallier])
+			if !ok {
+				run.complain(guardian, Withholding, PhaseTally)
+			} else if !contributions[tallier].VerifyGuardianDecryption(share, C1, curve) {
+				run.complain(guardian, WrongPartialDecryption, PhaseTally)
+			} else {
+				shares[tallier] = append(shares[tallier], share)
+			}
+		}
+	}
+
+	tally, err := election.Decrypt(accumulator, lo.Map(qualified, func(node pki.DkgParty, _ int) pki.DkgContribution {
+		return contributions[node.Index]
+	}), shares, curve)
+	if err != nil {
+		report.Err = err
+		return run.finish(report)
+	}
+	report.Tally = tally
+	report.TallyCorrect = reflect.DeepEqual(report.Tally, report.Expected)
+	return run.finish(report)
+}
+
+func (b *byzantineRun) finish(report ByzantineReport) ByzantineReport {
+	report.Complaints = b.complaints
+	report.Blamed = lo.Uniq(lo.Map(b.complaints, func(c Complaint, _ int) int { return c.Against }))
+	sort.Ints(report.Blamed)
+	report.FalselyBlamed = lo.Filter(report.Blamed, func(index int, _ int) bool { return b.adversary[index] == Honest })
+
+	for _, party := range sortedKeys(b.adversary) {
+		behaviour := b.adversary[party]
+		if behaviour == Honest {
+			continue
+		}
+		report.Faults = append(report.Faults, Fault{
+			Party:     party,
+			Behaviour: behaviour,
+			Committed: b.committed[party],
+			Detected:  lo.ContainsBy(b.complaints, func(c Complaint) bool { return c.Against == party }),
+			Attributed: lo.ContainsBy(b.complaints, func(c Complaint) bool {
+				return c.Reason == behaviour && c.Against == party
+			}),
+		})
+	}
+	return report
+}
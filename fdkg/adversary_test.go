@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/delendum-xyz/private-voting/fdkg/common"
+	"github.com/delendum-xyz/private-voting/fdkg/pki"
+	"github.com/samber/lo"
+)
+
+func TestHonestElectionHasNoComplaints(t *testing.T) {
+	pki.Output = io.Discard
+	config := common.VotingConfig{
+		Size:          6,
+		Options:       2,
+		Threshold:     2,
+		GuardiansSize: 3,
+	}
+	report := RunByzantineElection(config, 6, Adversary{}, curve, rand.New(rand.NewSource(0)))
+	if report.Err != nil {
+		t.Fatal(report.Err)
+	}
+	if len(report.Complaints) != 0 {
+		t.Errorf("Expected no complaints, got %v", report.Complaints)
+	}
+	if !report.TallyCorrect {
+		t.Errorf("Expected tally %v, got %v", report.Expected, report.Tally)
+	}
+}
+
+func TestEachBehaviourIsDetectedAndAttributed(t *testing.T) {
+	pki.Output = io.Discard
+	config := common.VotingConfig{
+		Size:          7,
+		Options:       4,
+		Threshold:     2,
+		GuardiansSize: 4,
+	}
+	for _, behaviour := range Behaviours {
+		for seed := int64(0); seed < 5; seed++ {
+			r := rand.New(rand.NewSource(seed))
+			adversary := Corrupt(lo.RangeFrom(1, config.Size), 1, []Behaviour{behaviour}, r)
+			report := RunByzantineElection(config, config.Size, adversary, curve, r)
+			if report.Err != nil {
+				t.Fatalf("%v/%d: %v", behaviour, seed, report.Err)
+			}
+			for _, fault := range report.Faults {
+				if !fault.Committed || !fault.Detected || !fault.Attributed {
+					t.Errorf("%v/%d: fault not caught: %+v", behaviour, seed, fault)
+				}
+			}
+			if len(report.FalselyBlamed) != 0 {
+				t.Errorf("%v/%d: honest parties blamed: %v", behaviour, seed, report.FalselyBlamed)
+			}
+			if !report.TallyCorrect {
+				t.Errorf("%v/%d: expected tally %v, got %v", behaviour, seed, report.Expected, report.Tally)
+			}
+		}
+	}
+}
+
+func TestWithholdingBeyondThresholdFails(t *testing.T) {
+	pki.Output = io.Discard
+	config := common.VotingConfig{
+		Size:          6,
+		Options:       2,
+		Threshold:     2,
+		GuardiansSize: 3,
+	}
+	r := rand.New(rand.NewSource(0))
+	// with five of six parties silent, no withholding tallier has two guardians left
+	adversary := Corrupt(lo.RangeFrom(1, config.Size), 5, []Behaviour{Withholding}, r)
+	report := RunByzantineElection(config, config.Size, adversary, curve, r)
+	if !errors.Is(report.Err, pki.ErrNotEnoughShares) {
+		t.Errorf("Expected ErrNotEnoughShares, got %v", report.Err)
+	}
+}
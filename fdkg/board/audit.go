@@ -0,0 +1,119 @@
+package board
+
+import (
+	"crypto/elliptic"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+
+	"github.com/delendum-xyz/private-voting/fdkg/common"
+	"github.com/samber/lo"
+)
+
+var (
+	ErrNoAnnouncedResult = errors.New("no result announced")
+	ErrAggregateMismatch = errors.New("announced aggregate differs from the sum of the accepted ballots")
+	ErrResultMismatch    = errors.New("announced tally differs from the decrypted tally")
+)
+
+// Finding is a failed check of an audit. Party is 0 for checks of the
+// election as a whole. Transcript entries are not signed, so a rejected entry
+// can only be attributed to the author it names: Claimed is set when Party
+// comes from there, as anyone could have published the entry under that index.
+type Finding struct {
+	Party   int
+	Check   string
+	Err     error
+	Claimed bool
+}
+
+// AuditReport is the outcome of checking a public election record.
+type AuditReport struct {
+	Config common.VotingConfig
+	// Parties lists the authors the entries claim, by index.
+	Parties   []int
+	Findings  []Finding
+	Tally     []int
+	Announced *Result
+}
+
+func (r AuditReport) Passed() bool {
+	return len(r.Findings) == 0
+}
+
+// FindingsFor returns the failed checks attributed to the party.
+func (r AuditReport) FindingsFor(party int) []Finding {
+	return lo.Filter(r.Findings, func(f Finding, _ int) bool { return f.Party == party })
+}
+
+// Audit checks a public election record, in the transcript format, without
+// any secrets: it verifies every contribution, ballot validity proof and
+// decryption proof, the latter in one batch, recomputes the aggregated C1 and C2, redoes the
+// reconstruction of absent talliers and the decoding, and compares the
+// outcome with the announced result. Only a malformed record is an error;
+// failed checks are reported as findings.
+func Audit(r io.Reader, curve elliptic.Curve) (AuditReport, error) {
+	entries, err := ReadTranscript(r)
+	if err != nil {
+		return AuditReport{}, err
+	}
+	if len(entries) == 0 || entries[0].Kind != KindConfig {
+		return AuditReport{}, fmt.Errorf("%w: does not start with the config", ErrInvalidTranscript)
+	}
+	var config common.VotingConfig
+	if err := json.Unmarshal(entries[0].Payload, &config); err != nil {
+		return AuditReport{}, fmt.Errorf("%w: config: %v", ErrInvalidTranscript, err)
+	}
+	b, err := New(config, curve, nil)
+	if err != nil {
+		return AuditReport{}, err
+	}
+	b.RequireBallotProofs = true
+
+	report := AuditReport{Config: config}
+	for i, entry := range entries[1:] {
+		if entry.Kind == KindDecryption && b.batched == nil {
+			b.batchDecryptions(entries[1+i:])
+		}
+		if err := b.Apply(entry); err != nil && errors.Is(err, ErrInvalidTranscript) {
+			return AuditReport{}, err
+		}
+		if entry.Kind != KindResult {
+			report.Parties = append(report.Parties, entry.Author)
+		}
+	}
+	report.Parties = lo.Uniq(report.Parties)
+	sort.Ints(report.Parties)
+	for _, rejection := range b.Rejected {
+		report.Findings = append(report.Findings, Finding{Party: rejection.Author, Check: string(rejection.Kind), Err: rejection.Err, Claimed: true})
+	}
+
+	report.Announced = b.Announced
+	C1, C2 := b.Aggregate()
+	if b.Announced == nil {
+		report.Findings = append(report.Findings, Finding{Check: "result", Err: ErrNoAnnouncedResult})
+	} else if !b.Announced.C1.Equal(C1) || !b.Announced.C2.Equal(C2) {
+		report.Findings = append(report.Findings, Finding{Check: "aggregate", Err: ErrAggregateMismatch})
+	}
+
+	reconstructed := true
+	for _, c := range b.Contributions() {
+		if _, err := b.PartialDecryption(c.Index); err != nil {
+			reconstructed = false
+			report.Findings = append(report.Findings, Finding{Party: c.Index, Check: "reconstruction", Err: err})
+		}
+	}
+	if !reconstructed {
+		return report, nil
+	}
+	report.Tally, err = b.Tally()
+	if err != nil {
+		report.Findings = append(report.Findings, Finding{Check: "decoding", Err: err})
+	} else if b.Announced != nil && !reflect.DeepEqual(report.Tally, b.Announced.Tally) {
+		report.Findings = append(report.Findings, Finding{Check: "result", Err: fmt.Errorf("%w: %v announced, %v decrypted", ErrResultMismatch, b.Announced.Tally, report.Tally)})
+	}
+	return report, nil
+}
@@ -0,0 +1,119 @@
+package board
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// tamper rewrites the payload of the first entry of the kind that satisfies
+// edit, which reports whether it changed the payload.
+func tamper(t *testing.T, transcript string, kind Kind, edit func(author int, payload map[string]interface{}) bool) string {
+	lines := strings.Split(strings.TrimSpace(transcript), "\n")
+	for i, line := range lines {
+		var entry Entry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatal(err)
+		}
+		var payload map[string]interface{}
+		if entry.Kind != kind || json.Unmarshal(entry.Payload, &payload) != nil || !edit(entry.Author, payload) {
+			continue
+		}
+		raw, err := json.Marshal(payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entry.Payload = raw
+		encoded, err := json.Marshal(entry)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines[i] = string(encoded)
+		return strings.Join(lines, "\n")
+	}
+	t.Fatalf("No %s entry to tamper with", kind)
+	return ""
+}
+
+func TestAuditFixtures(t *testing.T) {
+	for _, name := range []string{"election.jsonl", "reconstruction.jsonl"} {
+		file, err := os.Open(filepath.Join("testdata", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		report, err := Audit(file, curve)
+		file.Close()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !report.Passed() || report.Tally == nil {
+			t.Errorf("%s: expected the audit to pass, got %v", name, report.Findings)
+		}
+		if len(report.Parties) != report.Config.Size {
+			t.Errorf("%s: expected %d parties, got %v", name, report.Config.Size, report.Parties)
+		}
+	}
+}
+
+func TestAuditNamesOffendingParties(t *testing.T) {
+	var buffer bytes.Buffer
+	runElection(t, &buffer, map[int]bool{2: true}, 2)
+	transcript := buffer.String()
+
+	var voter int
+	invalidBallot := tamper(t, transcript, KindBallot, func(author int, payload map[string]interface{}) bool {
+		voter = author
+		payload["c2"] = payload["c1"]
+		return true
+	})
+	var guardian int
+	wrongDecryption := tamper(t, transcript, KindDecryption, func(author int, payload map[string]interface{}) bool {
+		if payload["dealer"].(float64) != 2 {
+			return false
+		}
+		guardian = author
+		payload["z"] = "1"
+		return true
+	})
+	wrongResult := tamper(t, transcript, KindResult, func(_ int, payload map[string]interface{}) bool {
+		payload["tally"] = []int{-1}
+		return true
+	})
+
+	cases := []struct {
+		name       string
+		transcript string
+		party      int
+		check      string
+	}{
+		{"ballot", invalidBallot, voter, string(KindBallot)},
+		{"decryption", wrongDecryption, guardian, string(KindDecryption)},
+		{"result", wrongResult, 0, "result"},
+	}
+	for _, c := range cases {
+		report, err := Audit(strings.NewReader(c.transcript), curve)
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if report.Passed() {
+			t.Errorf("%s: expected the audit to fail", c.name)
+			continue
+		}
+		findings := report.FindingsFor(c.party)
+		if len(findings) == 0 || findings[0].Check != c.check {
+			t.Errorf("%s: expected a %s finding for party %d, got %v", c.name, c.check, c.party, report.Findings)
+		} else if claimed := c.party != 0; findings[0].Claimed != claimed {
+			t.Errorf("%s: expected the finding to be claimed %v, got %v", c.name, claimed, findings[0].Claimed)
+		}
+	}
+
+	// the rejected ballot was part of the announced aggregate
+	report, _ := Audit(strings.NewReader(invalidBallot), curve)
+	if election := report.FindingsFor(0); len(election) == 0 || !errors.Is(election[0].Err, ErrAggregateMismatch) {
+		t.Errorf("Expected an aggregate mismatch, got %v", report.Findings)
+	}
+}
@@ -0,0 +1,378 @@
+// Package board is the public bulletin board of an election. Parties publish
+// their DKG contributions, ballots and proven partial decryptions to it; the
+// board checks every message before accepting it, tallies the accepted ones
+// and can record everything published to a transcript.
+package board
+
+import (
+	"crypto/elliptic"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/delendum-xyz/private-voting/fdkg/common"
+	"github.com/delendum-xyz/private-voting/fdkg/elgamal"
+	"github.com/delendum-xyz/private-voting/fdkg/eligibility"
+	"github.com/delendum-xyz/private-voting/fdkg/pki"
+	"github.com/samber/lo"
+)
+
+var (
+	ErrPhaseClosed    = errors.New("phase is closed")
+	ErrDuplicate      = errors.New("party already published this message")
+	ErrInvalidMessage = errors.New("invalid message")
+	ErrNoResult       = errors.New("not enough decryption shares to tally")
+)
+
+// Rejection is a published message the board refused.
+type Rejection struct {
+	Seq    int
+	Kind   Kind
+	Author int
+	Err    error
+}
+
+// Result is the announced outcome of the election together with the
+// aggregated ballots it was decrypted from.
+type Result struct {
+	Tally  []int
+	C1, C2 common.Point
+}
+
+type decryptionKey struct {
+	author, dealer int
+}
+
+// batchedDecryption is the verdict of a batch verification on a decryption
+// share to be published: whether it verified against publicKey and C1.
+type batchedDecryption struct {
+	C1, publicKey common.Point
+	valid         bool
+}
+
+// Board holds the accepted messages of one election. The phases follow each
+// other: the first ballot closes the DKG and the first decryption share
+// closes the voting. With an eligibility root only the latest ballot of each
+// nullifier is counted; without one every author casts a single ballot.
+// Contributions, ballot proofs, membership proofs and decryption proofs are
+// only accepted if they are bound to the board's Election.
+type Board struct {
+	Config   common.VotingConfig
+	Election common.Hash
+	// RequireBallotProofs rejects ballots published without a validity proof.
+	RequireBallotProofs bool
+	// Announced is the result published with Announce, if any.
+	Announced *Result
+	Rejected  []Rejection
+
+	curve         elliptic.Curve
+	transcript    *TranscriptWriter
+	seq           int
+	contributions []pki.DkgContribution
+	key           *common.Point
+	ballots       []common.EncryptedBallot
+	voters        map[int]bool
+	nullifiers    map[common.Nullifier]int
+	members       map[common.Hash]common.Nullifier
+	owners        map[common.Nullifier]common.Hash
+	decryptions   map[decryptionKey]pki.DecryptionShare
+	batched       map[int]batchedDecryption
+	c1, c2        common.Point
+}
+
+// New opens a board for the election. If transcript is not nil, every message
+// published from now on, accepted or not, is appended to it, starting with
+// the config.
+func New(config common.VotingConfig, curve elliptic.Curve, transcript io.Writer) (*Board, error) {
+	b := &Board{
+		Config:      config,
+		Election:    config.ElectionID(),
+		curve:       curve,
+		voters:      make(map[int]bool),
+		nullifiers:  make(map[common.Nullifier]int),
+		members:     make(map[common.Hash]common.Nullifier),
+		owners:      make(map[common.Nullifier]common.Hash),
+		decryptions: make(map[decryptionKey]pki.DecryptionShare),
+		c1:          common.PointZero(),
+		c2:          common.PointZero(),
+	}
+	if transcript != nil {
+		b.transcript = NewTranscriptWriter(transcript)
+	}
+	if err := b.record(KindConfig, 0, config); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (b *Board) record(kind Kind, author int, payload interface{}) error {
+	b.seq++
+	if b.transcript == nil {
+		return nil
+	}
+	return b.transcript.Append(b.Election, b.seq, kind, author, payload)
+}
+
+// publish records the message and then applies it. The returned error is a
+// transcript write failure or the reason the message was rejected.
+func (b *Board) publish(kind Kind, author int, payload interface{}, apply func() error) error {
+	if err := b.record(kind, author, payload); err != nil {
+		return err
+	}
+	if err := apply(); err != nil {
+		err = fmt.Errorf("party %d: %s: %w", author, kind, err)
+		b.Rejected = append(b.Rejected, Rejection{Seq: b.seq, Kind: kind, Author: author, Err: err})
+		return err
+	}
+	return nil
+}
+
+func (b *Board) PublishContribution(c pki.DkgContribution) error {
+	return b.publish(KindContribution, c.Index, contributionToJSON(c), func() error {
+		if len(b.ballots) > 0 {
+			return ErrPhaseClosed
+		}
+		if _, found := b.Contribution(c.Index); found {
+			return ErrDuplicate
+		}
+		if err := c.Validate(b.Config); err != nil {
+			return err
+		}
+		for _, commitment := range c.Commitments {
+			if !commitment.IsOnCurve(b.curve) {
+				return fmt.Errorf("%w: commitment not on curve", ErrInvalidMessage)
+			}
+		}
+		b.contributions = append(b.contributions, c)
+		return nil
+	})
+}
+
+// PublishRefresh replaces a tallier's contribution after its shares were
+// refreshed for a new guardian set. It is accepted until the first decryption
+// share, as long as the voting public key stays the same.
+func (b *Board) PublishRefresh(c pki.DkgContribution) error {
+	return b.publish(KindRefresh, c.Index, contributionToJSON(c), func() error {
+		if len(b.decryptions) > 0 {
+			return ErrPhaseClosed
+		}
+		i := lo.IndexOf(lo.Map(b.contributions, func(old pki.DkgContribution, _ int) int { return old.Index }), c.Index)
+		if i < 0 {
+			return fmt.Errorf("%w: no contribution from party %d", ErrInvalidMessage, c.Index)
+		}
+		if err := b.contributions[i].VerifyRefresh(c, b.Config); err != nil {
+			return err
+		}
+		for _, commitment := range c.Commitments {
+			if !commitment.IsOnCurve(b.curve) {
+				return fmt.Errorf("%w: commitment not on curve", ErrInvalidMessage)
+			}
+		}
+		b.contributions[i] = c
+		return nil
+	})
+}
+
+// PublishBallot accepts a ballot encrypted under EncryptionKey. The proof may
+// be nil unless RequireBallotProofs is set; a given proof must verify. If the
+// election has an eligibility root, the ballot must carry a membership proof,
+// which signs the nullifier and the ballot with an eligible key. A nullifier
+// belongs to the first key that casts under it and each key may only ever use
+// one nullifier; a ballot with the nullifier of an earlier one of the same key
+// replaces it, so voters can revote until the tally starts. Without a
+// root nothing binds a nullifier to its voter, so each author casts one
+// ballot, under a nullifier not used before, and cannot replace it.
+func (b *Board) PublishBallot(voter int, nullifier common.Nullifier, ballot common.EncryptedBallot, proof *elgamal.BallotProof, membership *eligibility.Membership) error {
+	return b.publish(KindBallot, voter, ballotToJSON(nullifier, ballot, proof, membership), func() error {
+		if len(b.contributions) == 0 || len(b.decryptions) > 0 {
+			return ErrPhaseClosed
+		}
+		if !ballot.C1.IsOnCurve(b.curve) || !ballot.C2.IsOnCurve(b.curve) {
+			return fmt.Errorf("%w: ballot not on curve", ErrInvalidMessage)
+		}
+		if proof == nil && b.RequireBallotProofs {
+			return fmt.Errorf("%w: ballot without validity proof", ErrInvalidMessage)
+		}
+		if proof != nil && !proof.Verify(ballot, b.Config.Options, b.Election, b.EncryptionKey(), b.curve) {
+			return fmt.Errorf("%w: ballot validity proof does not verify", ErrInvalidMessage)
+		}
+		if b.Config.EligibilityRoot.IsZero() {
+			if b.voters[voter] {
+				return ErrDuplicate
+			}
+			if _, found := b.nullifiers[nullifier]; found {
+				return fmt.Errorf("%w: nullifier already used", ErrDuplicate)
+			}
+			b.voters[voter] = true
+		} else {
+			if membership == nil {
+				return fmt.Errorf("%w: ballot without membership proof", ErrInvalidMessage)
+			}
+			if err := membership.Verify(b.Config.EligibilityRoot, b.Election, nullifier, ballot, b.curve); err != nil {
+				return fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+			}
+			leaf := eligibility.Leaf(membership.PublicKey, b.curve)
+			if used, found := b.members[leaf]; found && used != nullifier {
+				return fmt.Errorf("%w: eligible key already voted under another nullifier", ErrDuplicate)
+			}
+			if owner, found := b.owners[nullifier]; found && owner != leaf {
+				return fmt.Errorf("%w: nullifier belongs to another eligible key", ErrDuplicate)
+			}
+			b.members[leaf] = nullifier
+			b.owners[nullifier] = leaf
+		}
+		if i, found := b.nullifiers[nullifier]; found {
+			previous := b.ballots[i]
+			b.ballots[i] = ballot
+			b.c1 = b.add(b.c1, negate(previous.C1, b.curve))
+			b.c2 = b.add(b.c2, negate(previous.C2, b.curve))
+		} else {
+			b.nullifiers[nullifier] = len(b.ballots)
+			b.ballots = append(b.ballots, ballot)
+		}
+		b.c1 = b.add(b.c1, ballot.C1)
+		b.c2 = b.add(b.c2, ballot.C2)
+		return nil
+	})
+}
+
+func (b *Board) add(p, q common.Point) common.Point {
+	return common.BigIntToPoint(b.curve.Add(&p.X, &p.Y, &q.X, &q.Y))
+}
+
+func negate(p common.Point, curve elliptic.Curve) common.Point {
+	if p.X.Sign() == 0 && p.Y.Sign() == 0 {
+		return p
+	}
+	y := new(big.Int).Neg(&p.Y)
+	return common.BigIntToPoint(&p.X, y.Mod(y, curve.Params().P))
+}
+
+// PublishDecryptionShare accepts the dealer's own partial decryption d_i*C1,
+// when d.Index is the dealer, or one of its guardians' f_i(j)*C1.
+func (b *Board) PublishDecryptionShare(dealer int, d pki.DecryptionShare) error {
+	return b.publish(KindDecryption, d.Index, decryptionToJSON(dealer, d), func() error {
+		if len(b.ballots) == 0 {
+			return ErrPhaseClosed
+		}
+		contribution, found := b.Contribution(dealer)
+		if !found {
+			return fmt.Errorf("%w: no contribution from party %d", ErrInvalidMessage, dealer)
+		}
+		key := decryptionKey{author: d.Index, dealer: dealer}
+		if _, found := b.decryptions[key]; found {
+			return ErrDuplicate
+		}
+		publicKey, valid := b.shareKey(contribution, d.Index)
+		if verdict, found := b.batched[b.seq]; valid && found && verdict.C1.Equal(b.c1) && verdict.publicKey.Equal(publicKey) {
+			valid = verdict.valid
+		} else if valid {
+			valid = d.Verify(publicKey, b.c1, contribution.Election, b.curve)
+		}
+		if !valid {
+			return fmt.Errorf("%w: decryption proof for party %d does not verify", ErrInvalidMessage, dealer)
+		}
+		b.decryptions[key] = d
+		return nil
+	})
+}
+
+// shareKey is the key a decryption share by author for the contribution's
+// dealer is checked against: the dealer's voting key share d_i*G, or the share
+// commitment f_i(j)*G of one of its guardians.
+func (b *Board) shareKey(c pki.DkgContribution, author int) (common.Point, bool) {
+	if author == c.Index {
+		return c.VotingPublicKey, true
+	}
+	if !lo.Contains(c.Guardians, author) {
+		return common.Point{}, false
+	}
+	return c.ExpectedShare(author, b.curve), true
+}
+
+// Announce publishes the election result along with the current aggregate.
+func (b *Board) Announce(tally []int) error {
+	return b.announce(Result{Tally: tally, C1: b.c1, C2: b.c2})
+}
+
+func (b *Board) announce(result Result) error {
+	return b.publish(KindResult, 0, resultToJSON(result), func() error {
+		b.Announced = &result
+		return nil
+	})
+}
+
+func (b *Board) Contribution(index int) (pki.DkgContribution, bool) {
+	return lo.Find(b.contributions, func(c pki.DkgContribution) bool { return c.Index == index })
+}
+
+func (b *Board) Contributions() []pki.DkgContribution {
+	return b.contributions
+}
+
+// Ballots are the counted ballots, the latest of each nullifier.
+func (b *Board) Ballots() []common.EncryptedBallot {
+	return b.ballots
+}
+
+// EncryptionKey is the sum of the voting public keys of all contributions. It
+// is fixed once the first ballot is published.
+func (b *Board) EncryptionKey() common.Point {
+	if b.key != nil {
+		return *b.key
+	}
+	key := lo.Reduce(b.contributions, func(sum common.Point, c pki.DkgContribution, _ int) common.Point {
+		return common.BigIntToPoint(b.curve.Add(&sum.X, &sum.Y, &c.VotingPublicKey.X, &c.VotingPublicKey.Y))
+	}, common.PointZero())
+	if len(b.ballots) > 0 {
+		b.key = &key
+	}
+	return key
+}
+
+// Aggregate is the sum (C1, C2) of the counted ballots.
+func (b *Board) Aggregate() (common.Point, common.Point) {
+	return b.c1, b.c2
+}
+
+// PartialDecryption is d_i*C1 of the dealer: its own share if it published
+// one, otherwise reconstructed from its guardians' shares.
+func (b *Board) PartialDecryption(dealer int) (common.Point, error) {
+	if own, found := b.decryptions[decryptionKey{author: dealer, dealer: dealer}]; found {
+		return own.Value, nil
+	}
+	contribution, found := b.Contribution(dealer)
+	if !found {
+		return common.Point{}, fmt.Errorf("%w: no contribution from party %d", ErrInvalidMessage, dealer)
+	}
+	var partials []common.PartialDecryption
+	for _, guardian := range contribution.Guardians {
+		if d, found := b.decryptions[decryptionKey{author: guardian, dealer: dealer}]; found {
+			partials = append(partials, d.PartialDecryption)
+		}
+	}
+	return contribution.ReconstructPartialDecryption(partials, b.curve)
+}
+
+// Tally decrypts the sum of the accepted ballots.
+func (b *Board) Tally() (result []int, err error) {
+	defer func() {
+		// the exhaustive search panics when the sum encodes no valid tally
+		if r := recover(); r != nil {
+			result, err = nil, fmt.Errorf("%w: %v", ErrNoResult, r)
+		}
+	}()
+	if len(b.ballots) == 0 {
+		return nil, fmt.Errorf("%w: no ballots", ErrNoResult)
+	}
+	Z := common.PointZero()
+	for _, c := range b.contributions {
+		partial, err := b.PartialDecryption(c.Index)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrNoResult, err)
+		}
+		Z = common.BigIntToPoint(b.curve.Add(&Z.X, &Z.Y, &partial.X, &partial.Y))
+	}
+	return elgamal.DecryptResults(Z, b.c2, len(b.ballots), b.Config.Options, b.curve), nil
+}
@@ -0,0 +1,507 @@
+package board
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"io"
+	"math/big"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/delendum-xyz/private-voting/fdkg/common"
+	"github.com/delendum-xyz/private-voting/fdkg/elgamal"
+	"github.com/delendum-xyz/private-voting/fdkg/eligibility"
+	"github.com/delendum-xyz/private-voting/fdkg/pki"
+	"github.com/delendum-xyz/private-voting/fdkg/sss"
+	"github.com/samber/lo"
+	"github.com/torusresearch/pvss/secp256k1"
+)
+
+var curve = secp256k1.Curve
+
+var update = flag.Bool("update", false, "rewrite the transcripts in testdata")
+
+// runElection publishes a whole election to a board recording into w. Talliers
+// in absent do not return and are reconstructed from their guardians.
+func runElection(t *testing.T, w io.Writer, absent map[int]bool, seed int64) *Board {
+	pki.Output = io.Discard
+	config := common.VotingConfig{
+		Size:          6,
+		Options:       2,
+		Threshold:     2,
+		GuardiansSize: 3,
+	}
+	r := rand.New(rand.NewSource(seed))
+	localNodes, dkgNodes, err := pki.GenerateSetOfNodes(config, 4, pki.UniformSelector{}, curve, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry, err := eligibility.NewTree(lo.Map(localNodes, func(node pki.LocalParty, _ int) common.Point { return node.PublicKey }), curve)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.EligibilityRoot = registry.Root()
+	b, err := New(config, curve, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	received := make(map[int][]sss.Share)
+	for _, node := range dkgNodes {
+		if err := b.PublishContribution(node.Contribution(curve)); err != nil {
+			t.Fatal(err)
+		}
+		for _, share := range node.GenerateShares(curve) {
+			received[share.To] = append(received[share.To], share)
+		}
+	}
+	for _, node := range localNodes {
+		ballot, proof := elgamal.EncryptBallotWithProof(node.Vote(), config.Options, b.Election, b.EncryptionKey(), curve, r)
+		membership, err := eligibility.NewMembership(registry, node.PrivateKey, b.Election, node.Nullifier(), ballot, curve)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := b.PublishBallot(node.Index, node.Nullifier(), ballot, &proof, &membership); err != nil {
+			t.Fatal(err)
+		}
+	}
+	C1, _ := b.Aggregate()
+	for _, node := range dkgNodes {
+		if !absent[node.Index] {
+			if err := b.PublishDecryptionShare(node.Index, node.PartialDecryption(C1, curve)); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		for _, guardian := range node.TrustedParties {
+			share, _ := lo.Find(received[guardian.Index], func(s sss.Share) bool { return s.From == node.Index })
+			if err := b.PublishDecryptionShare(node.Index, pki.ProveDecryptionShare(guardian.Index, share.Value, C1, b.Election, curve)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	result, err := b.Tally()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := lo.CountBy(localNodes, func(node pki.LocalParty) bool { return node.Vote() == 1 })
+	if result[0] != expected {
+		t.Fatalf("Expected %d votes, got %v", expected, result)
+	}
+	if err := b.Announce(result); err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestReplayReproducesTally(t *testing.T) {
+	var transcript bytes.Buffer
+	original := runElection(t, &transcript, map[int]bool{}, 0)
+	// a rejected message stays in the transcript
+	if err := original.PublishBallot(1, common.Nullifier{1}, original.Ballots()[0], nil, nil); !errors.Is(err, ErrPhaseClosed) {
+		t.Errorf("Expected ErrPhaseClosed for a late ballot, got %v", err)
+	}
+
+	replayed, err := Replay(&transcript, curve)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := replayed.Tally()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(result, original.Announced.Tally) || !reflect.DeepEqual(replayed.Announced.Tally, original.Announced.Tally) {
+		t.Errorf("Replayed tally %v, announced %v, expected %v", result, replayed.Announced.Tally, original.Announced.Tally)
+	}
+	if !replayed.Announced.C1.Equal(original.Announced.C1) || !replayed.Announced.C2.Equal(original.Announced.C2) {
+		t.Errorf("Replayed aggregate differs from the announced one")
+	}
+	if len(replayed.Rejected) != 1 || replayed.Rejected[0].Seq != original.Rejected[0].Seq {
+		t.Errorf("Expected the late ballot to be rejected again, got %v", replayed.Rejected)
+	}
+}
+
+func TestBoardRejectsInvalidMessages(t *testing.T) {
+	b := runElection(t, nil, map[int]bool{}, 1)
+	contribution := b.Contributions()[0]
+	C1, _ := b.Aggregate()
+
+	if err := b.PublishContribution(contribution); !errors.Is(err, ErrPhaseClosed) {
+		t.Errorf("Expected ErrPhaseClosed, got %v", err)
+	}
+
+	unrestricted := b.Config
+	unrestricted.EligibilityRoot = common.Hash{}
+	fresh, err := New(unrestricted, curve, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fresh.RequireBallotProofs = true
+	for _, c := range b.Contributions() {
+		if err := fresh.PublishContribution(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ballot, proof := elgamal.EncryptBallotWithProof(1, b.Config.Options, fresh.Election, fresh.EncryptionKey(), curve, rand.New(rand.NewSource(0)))
+	if err := fresh.PublishBallot(1, common.Nullifier{1}, ballot, nil, nil); !errors.Is(err, ErrInvalidMessage) {
+		t.Errorf("Expected ErrInvalidMessage for a ballot without proof, got %v", err)
+	}
+	if err := fresh.PublishBallot(2, common.Nullifier{2}, b.Ballots()[0], &proof, nil); !errors.Is(err, ErrInvalidMessage) {
+		t.Errorf("Expected ErrInvalidMessage for a proof of another ballot, got %v", err)
+	}
+	if err := fresh.PublishBallot(3, common.Nullifier{3}, ballot, &proof, nil); err != nil {
+		t.Errorf("Valid ballot rejected: %v", err)
+	}
+
+	if err := b.PublishContribution(contribution); !errors.Is(err, ErrPhaseClosed) {
+		t.Errorf("Expected ErrPhaseClosed, got %v", err)
+	}
+	own := pki.ProveDecryptionShare(contribution.Index, *bigOne(), C1, b.Election, curve)
+	if err := b.PublishDecryptionShare(contribution.Index, own); !errors.Is(err, ErrDuplicate) {
+		t.Errorf("Expected ErrDuplicate, got %v", err)
+	}
+	forged := pki.ProveDecryptionShare(contribution.Guardians[0], *bigOne(), C1, b.Election, curve)
+	if err := b.PublishDecryptionShare(contribution.Index, forged); !errors.Is(err, ErrInvalidMessage) {
+		t.Errorf("Expected ErrInvalidMessage for a forged guardian share, got %v", err)
+	}
+}
+
+// TestTranscriptFixtures replays the recorded elections in testdata; run with
+// -update to record them again.
+func TestTranscriptFixtures(t *testing.T) {
+	fixtures := map[string]map[int]bool{
+		"election.jsonl":       {},
+		"reconstruction.jsonl": {1: true, 2: true, 3: true, 4: true, 5: true, 6: true},
+	}
+	for name, absent := range fixtures {
+		path := filepath.Join("testdata", name)
+		if *update {
+			var transcript bytes.Buffer
+			runElection(t, &transcript, absent, 0)
+			if err := os.WriteFile(path, transcript.Bytes(), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := Replay(file, curve)
+		file.Close()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		result, err := b.Tally()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(b.batched) == 0 {
+			t.Errorf("%s: decryption shares were not verified in a batch", name)
+		}
+		if len(b.Rejected) != 0 || b.Announced == nil || !reflect.DeepEqual(result, b.Announced.Tally) {
+			t.Errorf("%s: tally %v, announced %v, rejected %v", name, result, b.Announced, b.Rejected)
+		}
+	}
+}
+
+func bigOne() *big.Int {
+	return big.NewInt(1)
+}
+
+func TestRefreshAfterVoting(t *testing.T) {
+	pki.Output = io.Discard
+	config := common.VotingConfig{Size: 6, Options: 2, Threshold: 2, GuardiansSize: 3}
+	r := rand.New(rand.NewSource(3))
+	localNodes, dkgNodes, err := pki.GenerateSetOfNodes(config, 1, pki.UniformSelector{}, curve, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := New(config, curve, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	party := dkgNodes[0]
+	if err := b.PublishContribution(party.Contribution(curve)); err != nil {
+		t.Fatal(err)
+	}
+	for _, node := range localNodes {
+		ballot, proof := elgamal.EncryptBallotWithProof(node.Vote(), config.Options, b.Election, b.EncryptionKey(), curve, r)
+		if err := b.PublishBallot(node.Index, node.Nullifier(), ballot, &proof, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the tallier hands its share to new guardians before leaving
+	others := lo.Filter(localNodes, func(node pki.LocalParty, _ int) bool { return node.Index != party.Index })
+	refreshed, err := party.Refresh(lo.Map(others[:3], func(node pki.LocalParty, _ int) pki.PublicParty { return node.PublicParty }), 3, curve, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged := refreshed.Contribution(curve)
+	forged.Index = others[0].Index
+	if err := b.PublishRefresh(forged); !errors.Is(err, ErrInvalidMessage) {
+		t.Errorf("Expected ErrInvalidMessage for a refresh without contribution, got %v", err)
+	}
+	if err := b.PublishRefresh(refreshed.Contribution(curve)); err != nil {
+		t.Fatal(err)
+	}
+
+	C1, _ := b.Aggregate()
+	next, _ := b.Contribution(party.Index)
+	for _, share := range party.GenerateShares(curve) {
+		if !lo.Contains(next.Guardians, share.To) {
+			if err := b.PublishDecryptionShare(party.Index, pki.ProveDecryptionShare(share.To, share.Value, C1, b.Election, curve)); !errors.Is(err, ErrInvalidMessage) {
+				t.Errorf("Expected ErrInvalidMessage for a replaced guardian, got %v", err)
+			}
+		}
+	}
+	for _, share := range refreshed.GenerateShares(curve) {
+		if err := b.PublishDecryptionShare(party.Index, pki.ProveDecryptionShare(share.To, share.Value, C1, b.Election, curve)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	result, err := b.Tally()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := lo.CountBy(localNodes, func(node pki.LocalParty) bool { return node.Vote() == 1 }); result[0] != expected {
+		t.Errorf("Expected %d votes, got %v", expected, result)
+	}
+	if err := b.PublishRefresh(refreshed.Contribution(curve)); !errors.Is(err, ErrPhaseClosed) {
+		t.Errorf("Expected ErrPhaseClosed, got %v", err)
+	}
+}
+
+func TestRevoteReplacesBallot(t *testing.T) {
+	pki.Output = io.Discard
+	config := common.VotingConfig{Size: 4, Options: 2, Threshold: 2, GuardiansSize: 3}
+	r := rand.New(rand.NewSource(4))
+	localNodes, dkgNodes, err := pki.GenerateSetOfNodes(config, 4, pki.UniformSelector{}, curve, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry, err := eligibility.NewTree(lo.Map(localNodes, func(node pki.LocalParty, _ int) common.Point { return node.PublicKey }), curve)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.EligibilityRoot = registry.Root()
+	b, err := New(config, curve, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, node := range dkgNodes {
+		if err := b.PublishContribution(node.Contribution(curve)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cast := func(node pki.LocalParty, vote int) {
+		ballot, proof := elgamal.EncryptBallotWithProof(vote, config.Options, b.Election, b.EncryptionKey(), curve, r)
+		membership, err := eligibility.NewMembership(registry, node.PrivateKey, b.Election, node.Nullifier(), ballot, curve)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := b.PublishBallot(node.Index, node.Nullifier(), ballot, &proof, &membership); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, node := range localNodes {
+		cast(node, 1)
+	}
+	cast(localNodes[0], 0)
+	cast(localNodes[2], 0)
+	cast(localNodes[0], 1)
+	if len(b.Ballots()) != len(localNodes) {
+		t.Errorf("Expected %d counted ballots, got %d", len(localNodes), len(b.Ballots()))
+	}
+
+	C1, _ := b.Aggregate()
+	for _, node := range dkgNodes {
+		if err := b.PublishDecryptionShare(node.Index, node.PartialDecryption(C1, curve)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	result, err := b.Tally()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result[0] != 3 {
+		t.Errorf("Expected 3 yes votes after revoting, got %v", result)
+	}
+	ballot, _ := elgamal.EncryptBallotWithProof(0, config.Options, b.Election, b.EncryptionKey(), curve, r)
+	if err := b.PublishBallot(localNodes[1].Index, localNodes[1].Nullifier(), ballot, nil, nil); !errors.Is(err, ErrPhaseClosed) {
+		t.Errorf("Expected ErrPhaseClosed for a revote after the tally started, got %v", err)
+	}
+}
+
+func TestOneBallotPerAuthorWithoutRegistry(t *testing.T) {
+	pki.Output = io.Discard
+	config := common.VotingConfig{Size: 4, Options: 2, Threshold: 2, GuardiansSize: 3}
+	r := rand.New(rand.NewSource(4))
+	localNodes, dkgNodes, err := pki.GenerateSetOfNodes(config, 4, pki.UniformSelector{}, curve, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := New(config, curve, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, node := range dkgNodes {
+		if err := b.PublishContribution(node.Contribution(curve)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	alice, bob := localNodes[0], localNodes[1]
+	ballot := elgamal.EncryptBallot(1, config.Options, b.EncryptionKey(), curve, r)
+	if err := b.PublishBallot(alice.Index, alice.Nullifier(), ballot, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	revote := elgamal.EncryptBallot(0, config.Options, b.EncryptionKey(), curve, r)
+	if err := b.PublishBallot(alice.Index, common.Nullifier{1}, revote, nil, nil); !errors.Is(err, ErrDuplicate) {
+		t.Errorf("Expected ErrDuplicate for a second ballot of the same author, got %v", err)
+	}
+	if err := b.PublishBallot(bob.Index, alice.Nullifier(), revote, nil, nil); !errors.Is(err, ErrDuplicate) {
+		t.Errorf("Expected ErrDuplicate for a replayed nullifier, got %v", err)
+	}
+	if ballots := b.Ballots(); len(ballots) != 1 || !ballots[0].C1.Equal(ballot.C1) {
+		t.Errorf("The first ballot was not kept")
+	}
+}
+
+func TestBoardChecksEligibility(t *testing.T) {
+	pki.Output = io.Discard
+	config := common.VotingConfig{Size: 4, Options: 2, Threshold: 2, GuardiansSize: 3}
+	r := rand.New(rand.NewSource(5))
+	localNodes, dkgNodes, err := pki.GenerateSetOfNodes(config, 4, pki.UniformSelector{}, curve, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the last party is not registered
+	registry, err := eligibility.NewTree(lo.Map(localNodes[:3], func(node pki.LocalParty, _ int) common.Point { return node.PublicKey }), curve)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.EligibilityRoot = registry.Root()
+	b, err := New(config, curve, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, node := range dkgNodes {
+		if err := b.PublishContribution(node.Contribution(curve)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	alice := localNodes[0]
+	ballot, proof := elgamal.EncryptBallotWithProof(1, config.Options, b.Election, b.EncryptionKey(), curve, r)
+	membership, err := eligibility.NewMembership(registry, alice.PrivateKey, b.Election, alice.Nullifier(), ballot, curve)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.PublishBallot(alice.Index, alice.Nullifier(), ballot, &proof, nil); !errors.Is(err, ErrInvalidMessage) {
+		t.Errorf("Expected ErrInvalidMessage for a ballot without membership, got %v", err)
+	}
+	other, otherProof := elgamal.EncryptBallotWithProof(0, config.Options, b.Election, b.EncryptionKey(), curve, r)
+	if err := b.PublishBallot(alice.Index, alice.Nullifier(), other, &otherProof, &membership); !errors.Is(err, ErrInvalidMessage) {
+		t.Errorf("Expected ErrInvalidMessage for a membership copied onto another ballot, got %v", err)
+	}
+	if err := b.PublishBallot(alice.Index, alice.Nullifier(), ballot, &proof, &membership); err != nil {
+		t.Fatal(err)
+	}
+	again, err := eligibility.NewMembership(registry, alice.PrivateKey, b.Election, common.Nullifier{1}, other, curve)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.PublishBallot(alice.Index, common.Nullifier{1}, other, &otherProof, &again); !errors.Is(err, ErrDuplicate) {
+		t.Errorf("Expected ErrDuplicate for a second nullifier of the same key, got %v", err)
+	}
+	// bob is eligible and signs a ballot under alice's public nullifier
+	bob := localNodes[1]
+	stolen, err := eligibility.NewMembership(registry, bob.PrivateKey, b.Election, alice.Nullifier(), other, curve)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.PublishBallot(bob.Index, alice.Nullifier(), other, &otherProof, &stolen); !errors.Is(err, ErrDuplicate) {
+		t.Errorf("Expected ErrDuplicate for a ballot under another voter's nullifier, got %v", err)
+	}
+	if counted := b.Ballots(); len(counted) != 1 || !counted[0].C1.Equal(ballot.C1) {
+		t.Errorf("Another eligible voter replaced alice's ballot")
+	}
+	if _, err := eligibility.NewMembership(registry, localNodes[3].PrivateKey, b.Election, localNodes[3].Nullifier(), ballot, curve); !errors.Is(err, eligibility.ErrNotEligible) {
+		t.Errorf("Expected ErrNotEligible, got %v", err)
+	}
+	if len(b.Ballots()) != 1 {
+		t.Errorf("Expected 1 counted ballot, got %d", len(b.Ballots()))
+	}
+}
+
+func TestConcurrentElections(t *testing.T) {
+	pki.Output = io.Discard
+	registry := NewRegistry(curve)
+	first := common.VotingConfig{Name: "first", Size: 4, Options: 2, Threshold: 2, GuardiansSize: 3}
+	second := first
+	second.Name = "second"
+	r := rand.New(rand.NewSource(4))
+	_, firstNodes, err := pki.GenerateSetOfNodes(first, 1, pki.UniformSelector{}, curve, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	localNodes, secondNodes, err := pki.GenerateSetOfNodes(second, 1, pki.UniformSelector{}, curve, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := registry.Open(first, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := registry.Open(second, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Election == b.Election {
+		t.Fatalf("Elections with different names share the ID %v", a.Election)
+	}
+	if _, err := registry.Open(first, nil); !errors.Is(err, ErrDuplicate) {
+		t.Errorf("Expected ErrDuplicate for reopening an election, got %v", err)
+	}
+
+	if err := b.PublishContribution(firstNodes[0].Contribution(curve)); !errors.Is(err, common.ErrWrongElection) {
+		t.Errorf("Expected ErrWrongElection for a contribution to the first election, got %v", err)
+	}
+	party := secondNodes[0]
+	if err := b.PublishContribution(party.Contribution(curve)); err != nil {
+		t.Fatal(err)
+	}
+	replayed, proof := elgamal.EncryptBallotWithProof(1, second.Options, a.Election, b.EncryptionKey(), curve, r)
+	if err := b.PublishBallot(1, common.Nullifier{1}, replayed, &proof, nil); !errors.Is(err, ErrInvalidMessage) {
+		t.Errorf("Expected ErrInvalidMessage for a ballot proven in the first election, got %v", err)
+	}
+	for _, node := range localNodes {
+		ballot, proof := elgamal.EncryptBallotWithProof(node.Vote(), second.Options, b.Election, b.EncryptionKey(), curve, r)
+		if err := b.PublishBallot(node.Index, node.Nullifier(), ballot, &proof, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	C1, _ := b.Aggregate()
+	wrong := pki.ProveDecryptionShare(party.Index, party.VotingPrivKeyShare, C1, a.Election, curve)
+	if err := b.PublishDecryptionShare(party.Index, wrong); !errors.Is(err, ErrInvalidMessage) {
+		t.Errorf("Expected ErrInvalidMessage for a decryption proven in the first election, got %v", err)
+	}
+	if err := b.PublishDecryptionShare(party.Index, party.PartialDecryption(C1, curve)); err != nil {
+		t.Fatal(err)
+	}
+	result, err := registry.Tally(b.Election)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := lo.CountBy(localNodes, func(node pki.LocalParty) bool { return node.Vote() == 1 }); result[0] != expected {
+		t.Errorf("Expected %d votes, got %v", expected, result)
+	}
+	if _, err := registry.Tally(common.Hash{}); !errors.Is(err, common.ErrWrongElection) {
+		t.Errorf("Expected ErrWrongElection for an unknown election, got %v", err)
+	}
+}
@@ -0,0 +1,47 @@
+package board
+
+import (
+	"crypto/elliptic"
+	"fmt"
+	"io"
+
+	"github.com/delendum-xyz/private-voting/fdkg/common"
+)
+
+// Registry holds the boards of concurrent elections, keyed by election ID.
+type Registry struct {
+	curve  elliptic.Curve
+	boards map[common.Hash]*Board
+}
+
+func NewRegistry(curve elliptic.Curve) *Registry {
+	return &Registry{curve: curve, boards: make(map[common.Hash]*Board)}
+}
+
+// Open opens the board of a new election; see New.
+func (r *Registry) Open(config common.VotingConfig, transcript io.Writer) (*Board, error) {
+	id := config.ElectionID()
+	if _, found := r.boards[id]; found {
+		return nil, fmt.Errorf("%w: election %v already open", ErrDuplicate, id)
+	}
+	b, err := New(config, r.curve, transcript)
+	if err != nil {
+		return nil, err
+	}
+	r.boards[id] = b
+	return b, nil
+}
+
+func (r *Registry) Board(election common.Hash) (*Board, bool) {
+	b, found := r.boards[election]
+	return b, found
+}
+
+// Tally decrypts the result of the election.
+func (r *Registry) Tally(election common.Hash) ([]int, error) {
+	b, found := r.boards[election]
+	if !found {
+		return nil, fmt.Errorf("%w: election %v", common.ErrWrongElection, election)
+	}
+	return b.Tally()
+}
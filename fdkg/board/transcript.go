@@ -0,0 +1,431 @@
+package board
+
+import (
+	"bufio"
+	"crypto/elliptic"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/delendum-xyz/private-voting/fdkg/common"
+	"github.com/delendum-xyz/private-voting/fdkg/elgamal"
+	"github.com/delendum-xyz/private-voting/fdkg/eligibility"
+	"github.com/delendum-xyz/private-voting/fdkg/pki"
+	"github.com/delendum-xyz/private-voting/fdkg/schnorr"
+	"github.com/delendum-xyz/private-voting/fdkg/utils"
+	"github.com/samber/lo"
+)
+
+// Kind is the type of a published message.
+type Kind string
+
+const (
+	KindConfig       Kind = "config"
+	KindContribution Kind = "contribution"
+	KindRefresh      Kind = "refresh"
+	KindBallot       Kind = "ballot"
+	KindDecryption   Kind = "decryption"
+	KindResult       Kind = "result"
+)
+
+var ErrInvalidTranscript = errors.New("invalid transcript")
+
+// Entry is one line of a transcript: a message in the order it was published,
+// tagged with the election it belongs to.
+type Entry struct {
+	Election common.Hash     `json:"election"`
+	Seq      int             `json:"seq"`
+	Kind     Kind            `json:"kind"`
+	Author   int             `json:"author"`
+	Payload  json.RawMessage `json:"payload"`
+}
+
+// TranscriptWriter appends entries to a transcript as JSON lines.
+type TranscriptWriter struct {
+	encoder *json.Encoder
+}
+
+func NewTranscriptWriter(w io.Writer) *TranscriptWriter {
+	return &TranscriptWriter{encoder: json.NewEncoder(w)}
+}
+
+func (t *TranscriptWriter) Append(election common.Hash, seq int, kind Kind, author int, payload interface{}) error {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return t.encoder.Encode(Entry{Election: election, Seq: seq, Kind: kind, Author: author, Payload: raw})
+}
+
+type pointJSON struct {
+	X string `json:"x"`
+	Y string `json:"y"`
+}
+
+type contributionJSON struct {
+	Threshold   int         `json:"threshold"`
+	Guardians   []int       `json:"guardians"`
+	Commitments []pointJSON `json:"commitments"`
+}
+
+type ballotProofJSON struct {
+	Challenges []string `json:"challenges"`
+	Responses  []string `json:"responses"`
+}
+
+type membershipJSON struct {
+	PublicKey pointJSON     `json:"publicKey"`
+	Index     int           `json:"index"`
+	Siblings  []common.Hash `json:"siblings"`
+	R         pointJSON     `json:"r"`
+	S         string        `json:"s"`
+}
+
+type ballotJSON struct {
+	Nullifier  string           `json:"nullifier"`
+	C1         pointJSON        `json:"c1"`
+	C2         pointJSON        `json:"c2"`
+	Proof      *ballotProofJSON `json:"proof,omitempty"`
+	Membership *membershipJSON  `json:"membership,omitempty"`
+}
+
+type decryptionJSON struct {
+	Dealer int        `json:"dealer"`
+	Value  pointJSON  `json:"value"`
+	C      string     `json:"c"`
+	Z      string     `json:"z"`
+	A      *pointJSON `json:"a,omitempty"`
+	B      *pointJSON `json:"b,omitempty"`
+}
+
+type resultJSON struct {
+	Tally []int      `json:"tally"`
+	C1    *pointJSON `json:"c1,omitempty"`
+	C2    *pointJSON `json:"c2,omitempty"`
+}
+
+func bigToHex(b *big.Int) string {
+	return b.Text(16)
+}
+
+func hexToBig(s string) (big.Int, error) {
+	b, ok := new(big.Int).SetString(s, 16)
+	if !ok {
+		return big.Int{}, fmt.Errorf("%w: invalid hex %q", ErrInvalidTranscript, s)
+	}
+	return *b, nil
+}
+
+func pointToJSON(p common.Point) pointJSON {
+	return pointJSON{X: bigToHex(&p.X), Y: bigToHex(&p.Y)}
+}
+
+func pointFromJSON(p pointJSON) (common.Point, error) {
+	x, err := hexToBig(p.X)
+	if err != nil {
+		return common.Point{}, err
+	}
+	y, err := hexToBig(p.Y)
+	if err != nil {
+		return common.Point{}, err
+	}
+	return common.BigIntToPoint(&x, &y), nil
+}
+
+func contributionToJSON(c pki.DkgContribution) contributionJSON {
+	return contributionJSON{
+		Threshold:   c.Threshold,
+		Guardians:   c.Guardians,
+		Commitments: utils.Map(c.Commitments, pointToJSON),
+	}
+}
+
+func scalarsToJSON(scalars []big.Int) []string {
+	return utils.Map(scalars, func(s big.Int) string { return bigToHex(&s) })
+}
+
+func scalarsFromJSON(scalars []string) ([]big.Int, error) {
+	result := make([]big.Int, len(scalars))
+	for i, s := range scalars {
+		v, err := hexToBig(s)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = v
+	}
+	return result, nil
+}
+
+func ballotToJSON(nullifier common.Nullifier, b common.EncryptedBallot, proof *elgamal.BallotProof, membership *eligibility.Membership) ballotJSON {
+	ballot := ballotJSON{Nullifier: nullifier.String(), C1: pointToJSON(b.C1), C2: pointToJSON(b.C2)}
+	if proof != nil {
+		ballot.Proof = &ballotProofJSON{Challenges: scalarsToJSON(proof.Challenges), Responses: scalarsToJSON(proof.Responses)}
+	}
+	if membership != nil {
+		ballot.Membership = &membershipJSON{
+			PublicKey: pointToJSON(membership.PublicKey),
+			Index:     membership.Index,
+			Siblings:  membership.Siblings,
+			R:         pointToJSON(membership.Signature.R),
+			S:         bigToHex(&membership.Signature.S),
+		}
+	}
+	return ballot
+}
+
+func membershipFromJSON(m membershipJSON) (eligibility.Membership, error) {
+	publicKey, err := pointFromJSON(m.PublicKey)
+	if err != nil {
+		return eligibility.Membership{}, err
+	}
+	R, err := pointFromJSON(m.R)
+	if err != nil {
+		return eligibility.Membership{}, err
+	}
+	S, err := hexToBig(m.S)
+	if err != nil {
+		return eligibility.Membership{}, err
+	}
+	return eligibility.Membership{
+		Proof:     eligibility.Proof{PublicKey: publicKey, Index: m.Index, Siblings: m.Siblings},
+		Signature: schnorr.Signature{R: R, S: S},
+	}, nil
+}
+
+func resultToJSON(r Result) resultJSON {
+	C1, C2 := pointToJSON(r.C1), pointToJSON(r.C2)
+	return resultJSON{Tally: r.Tally, C1: &C1, C2: &C2}
+}
+
+func decryptionToJSON(dealer int, d pki.DecryptionShare) decryptionJSON {
+	c, z := new(big.Int), new(big.Int)
+	if d.Proof.C != nil {
+		c = d.Proof.C
+	}
+	if d.Proof.Z != nil {
+		z = d.Proof.Z
+	}
+	decryption := decryptionJSON{Dealer: dealer, Value: pointToJSON(d.Value), C: bigToHex(c), Z: bigToHex(z)}
+	if d.Proof.A != nil && d.Proof.B != nil {
+		A, B := pointToJSON(*d.Proof.A), pointToJSON(*d.Proof.B)
+		decryption.A, decryption.B = &A, &B
+	}
+	return decryption
+}
+
+// decryptionFromJSON decodes a decryption share entry into its dealer and the
+// share, with the proof's commitments if they were recorded.
+func decryptionFromJSON(entry Entry, curve elliptic.Curve) (int, pki.DecryptionShare, error) {
+	var d decryptionJSON
+	if err := json.Unmarshal(entry.Payload, &d); err != nil {
+		return 0, pki.DecryptionShare{}, fmt.Errorf("%w: seq %d: %v", ErrInvalidTranscript, entry.Seq, err)
+	}
+	value, err := pointFromJSON(d.Value)
+	if err != nil {
+		return 0, pki.DecryptionShare{}, err
+	}
+	c, err := hexToBig(d.C)
+	if err != nil {
+		return 0, pki.DecryptionShare{}, err
+	}
+	z, err := hexToBig(d.Z)
+	if err != nil {
+		return 0, pki.DecryptionShare{}, err
+	}
+	share := pki.RestoreDecryptionShare(entry.Author, value, c, z, curve)
+	if d.A != nil && d.B != nil {
+		A, err := pointFromJSON(*d.A)
+		if err != nil {
+			return 0, pki.DecryptionShare{}, err
+		}
+		B, err := pointFromJSON(*d.B)
+		if err != nil {
+			return 0, pki.DecryptionShare{}, err
+		}
+		share.Proof.A, share.Proof.B = &A, &B
+	}
+	return d.Dealer, share, nil
+}
+
+// ReadTranscript reads all entries of a transcript.
+func ReadTranscript(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidTranscript, len(entries)+1, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// Apply publishes a recorded entry to the board, as its author did. Entries of
+// another election make the transcript invalid.
+func (b *Board) Apply(entry Entry) error {
+	if entry.Election != b.Election {
+		return fmt.Errorf("%w: seq %d: %v: entry of election %v", ErrInvalidTranscript, entry.Seq, common.ErrWrongElection, entry.Election)
+	}
+	switch entry.Kind {
+	case KindContribution, KindRefresh:
+		var c contributionJSON
+		if err := json.Unmarshal(entry.Payload, &c); err != nil {
+			return fmt.Errorf("%w: seq %d: %v", ErrInvalidTranscript, entry.Seq, err)
+		}
+		commitments := make([]common.Point, len(c.Commitments))
+		for i, commitment := range c.Commitments {
+			point, err := pointFromJSON(commitment)
+			if err != nil {
+				return err
+			}
+			commitments[i] = point
+		}
+		contribution := pki.DkgContribution{Election: entry.Election, Index: entry.Author, Threshold: c.Threshold, Guardians: c.Guardians, Commitments: commitments}
+		if len(commitments) > 0 {
+			contribution.VotingPublicKey = commitments[0]
+		}
+		if entry.Kind == KindRefresh {
+			return b.PublishRefresh(contribution)
+		}
+		return b.PublishContribution(contribution)
+	case KindBallot:
+		var ballot ballotJSON
+		if err := json.Unmarshal(entry.Payload, &ballot); err != nil {
+			return fmt.Errorf("%w: seq %d: %v", ErrInvalidTranscript, entry.Seq, err)
+		}
+		C1, err := pointFromJSON(ballot.C1)
+		if err != nil {
+			return err
+		}
+		C2, err := pointFromJSON(ballot.C2)
+		if err != nil {
+			return err
+		}
+		var proof *elgamal.BallotProof
+		if ballot.Proof != nil {
+			challenges, err := scalarsFromJSON(ballot.Proof.Challenges)
+			if err != nil {
+				return err
+			}
+			responses, err := scalarsFromJSON(ballot.Proof.Responses)
+			if err != nil {
+				return err
+			}
+			proof = &elgamal.BallotProof{Challenges: challenges, Responses: responses}
+		}
+		var nullifier common.Nullifier
+		n, err := hex.DecodeString(ballot.Nullifier)
+		if err != nil || len(n) != len(nullifier) {
+			return fmt.Errorf("%w: seq %d: invalid nullifier %q", ErrInvalidTranscript, entry.Seq, ballot.Nullifier)
+		}
+		copy(nullifier[:], n)
+		var membership *eligibility.Membership
+		if ballot.Membership != nil {
+			m, err := membershipFromJSON(*ballot.Membership)
+			if err != nil {
+				return err
+			}
+			membership = &m
+		}
+		return b.PublishBallot(entry.Author, nullifier, common.EncryptedBallot{C1: C1, C2: C2}, proof, membership)
+	case KindDecryption:
+		dealer, share, err := decryptionFromJSON(entry, b.curve)
+		if err != nil {
+			return err
+		}
+		return b.PublishDecryptionShare(dealer, share)
+	case KindResult:
+		var result resultJSON
+		var err error
+		if err = json.Unmarshal(entry.Payload, &result); err != nil {
+			return fmt.Errorf("%w: seq %d: %v", ErrInvalidTranscript, entry.Seq, err)
+		}
+		announced := Result{Tally: result.Tally, C1: common.PointZero(), C2: common.PointZero()}
+		if result.C1 != nil && result.C2 != nil {
+			if announced.C1, err = pointFromJSON(*result.C1); err != nil {
+				return err
+			}
+			if announced.C2, err = pointFromJSON(*result.C2); err != nil {
+				return err
+			}
+		}
+		return b.announce(announced)
+	default:
+		return fmt.Errorf("%w: seq %d: unexpected %q message", ErrInvalidTranscript, entry.Seq, entry.Kind)
+	}
+}
+
+// Replay feeds a transcript into a fresh board. Messages the original board
+// rejected are rejected again and end up in Rejected; only a malformed
+// transcript is an error.
+func Replay(r io.Reader, curve elliptic.Curve) (*Board, error) {
+	entries, err := ReadTranscript(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 || entries[0].Kind != KindConfig {
+		return nil, fmt.Errorf("%w: does not start with the config", ErrInvalidTranscript)
+	}
+	var config common.VotingConfig
+	if err := json.Unmarshal(entries[0].Payload, &config); err != nil {
+		return nil, fmt.Errorf("%w: config: %v", ErrInvalidTranscript, err)
+	}
+	b, err := New(config, curve, nil)
+	if err != nil {
+		return nil, err
+	}
+	for i, entry := range entries[1:] {
+		if entry.Kind == KindDecryption && b.batched == nil {
+			b.batchDecryptions(entries[1+i:])
+		}
+		if err := b.Apply(entry); err != nil && errors.Is(err, ErrInvalidTranscript) {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// batchDecryptions verifies the decryption shares among entries, the rest of
+// a transcript from its first decryption share on, in one batch against the
+// board as it is now. PublishDecryptionShare takes the verdict of a share as
+// long as the aggregate C1 and the key the share is checked against are
+// still the same when it is applied.
+func (b *Board) batchDecryptions(entries []Entry) {
+	b.batched = make(map[int]batchedDecryption)
+	var seqs []int
+	var shares []pki.DecryptionShare
+	var publicKeys []common.Point
+	for i, entry := range entries {
+		if entry.Kind != KindDecryption || entry.Election != b.Election {
+			continue
+		}
+		dealer, share, err := decryptionFromJSON(entry, b.curve)
+		if err != nil {
+			continue
+		}
+		contribution, found := b.Contribution(dealer)
+		if !found {
+			continue
+		}
+		publicKey, ok := b.shareKey(contribution, share.Index)
+		if !ok {
+			continue
+		}
+		// every entry is recorded under the next sequence number
+		seqs = append(seqs, b.seq+1+i)
+		shares = append(shares, share)
+		publicKeys = append(publicKeys, publicKey)
+	}
+	invalid := pki.BatchVerifyDecryptionShares(shares, publicKeys, b.c1, b.Election, b.curve)
+	for i, seq := range seqs {
+		b.batched[seq] = batchedDecryption{C1: b.c1, publicKey: publicKeys[i], valid: !lo.Contains(invalid, i)}
+	}
+}
@@ -0,0 +1,70 @@
+// Command audit checks a public election record without holding any secrets
+// and prints a pass/fail report naming each offending party. A rejected entry
+// is attributed to the author it claims, which the record does not
+// authenticate. It exits with status 1 if any check fails.
+//
+//	go run ./cmd/audit -record election.jsonl
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/delendum-xyz/private-voting/fdkg/board"
+	"github.com/torusresearch/pvss/secp256k1"
+)
+
+func printReport(report board.AuditReport, w io.Writer) {
+	fmt.Fprintf(w, "election %v\n", report.Config.ElectionID())
+	for _, party := range report.Parties {
+		findings := report.FindingsFor(party)
+		if len(findings) == 0 {
+			fmt.Fprintf(w, "PASS Party_%d\n", party)
+			continue
+		}
+		fmt.Fprintf(w, "FAIL Party_%d\n", party)
+		for _, f := range findings {
+			if f.Claimed {
+				fmt.Fprintf(w, "  %s (claimed author): %v\n", f.Check, f.Err)
+			} else {
+				fmt.Fprintf(w, "  %s: %v\n", f.Check, f.Err)
+			}
+		}
+	}
+	for _, f := range report.FindingsFor(0) {
+		fmt.Fprintf(w, "FAIL election %s: %v\n", f.Check, f.Err)
+	}
+	if report.Tally != nil {
+		fmt.Fprintf(w, "tally: %v\n", report.Tally)
+	}
+	if report.Passed() {
+		fmt.Fprintln(w, "PASS")
+	} else {
+		fmt.Fprintln(w, "FAIL")
+	}
+}
+
+func main() {
+	path := flag.String("record", "", "public election record (transcript) to audit")
+	flag.Parse()
+	if *path == "" {
+		log.Fatal("missing -record")
+	}
+
+	file, err := os.Open(*path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+	report, err := board.Audit(file, secp256k1.Curve)
+	if err != nil {
+		log.Fatal(err)
+	}
+	printReport(report, os.Stdout)
+	if !report.Passed() {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/delendum-xyz/private-voting/fdkg/board"
+	"github.com/torusresearch/pvss/secp256k1"
+)
+
+func TestAuditFixture(t *testing.T) {
+	file, err := os.Open("../../board/testdata/reconstruction.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	report, err := board.Audit(file, secp256k1.Curve)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	printReport(report, &out)
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if lines[len(lines)-1] != "PASS" || strings.Count(out.String(), "PASS Party_") != report.Config.Size {
+		t.Errorf("Unexpected report:\n%s", out.String())
+	}
+}
@@ -0,0 +1,233 @@
+// Command cost runs one election and reports, for each phase and each party,
+// the messages sent, their serialized size, the scalar multiplications and the
+// wall time spent, as JSON. It reproduces the O(n·k) distribution and
+// reconstruction costs from the Go implementation, e.g. for the setting quoted
+// in the README:
+//
+//	go run ./cmd/cost -size 100 -participation 0.5 -retention 0.8 -guardians 40
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+
+	"github.com/delendum-xyz/private-voting/fdkg/common"
+	"github.com/delendum-xyz/private-voting/fdkg/cost"
+	"github.com/delendum-xyz/private-voting/fdkg/election"
+	"github.com/delendum-xyz/private-voting/fdkg/elgamal"
+	"github.com/delendum-xyz/private-voting/fdkg/pki"
+	"github.com/delendum-xyz/private-voting/fdkg/sss"
+	"github.com/samber/lo"
+	"github.com/torusresearch/pvss/secp256k1"
+)
+
+const (
+	PhaseSetup          = "setup"
+	PhaseDistribution   = "distribution"
+	PhaseVoting         = "voting"
+	PhaseTally          = "tally"
+	PhaseReconstruction = "reconstruction"
+	PhaseDecryption     = "decryption"
+)
+
+var errWrongTally = errors.New("decrypted tally does not match the votes")
+
+type setting struct {
+	config common.VotingConfig
+	// participation is the fraction of parties taking part in the DKG,
+	// retention the fraction of them returning to tally and newGuardians the
+	// fraction of the other parties available as guardians in the tally.
+	participation float64
+	retention     float64
+	newGuardians  float64
+}
+
+func sample(n int, fraction float64, r *rand.Rand) []int {
+	return r.Perm(n)[:int(math.Floor(float64(n)*fraction))]
+}
+
+func sortedKeys[T any](m map[int]T) []int {
+	keys := lo.Keys(m)
+	sort.Ints(keys)
+	return keys
+}
+
+// measureElection runs one election on meter.Curve through the phases of
+// fdkg/election, one party at a time, recording every message and
+// computation. Checking and combining the decryption shares is charged to
+// the aggregator in the decryption phase. An error means the tally could not
+// be decrypted; the costs of the phases up to then are recorded anyway.
+func measureElection(s setting, meter *cost.Meter, r *rand.Rand) error {
+	config, curve := s.config, meter.Curve
+
+	nodes := make([]pki.LocalParty, config.Size)
+	for i := range nodes {
+		meter.Measure(PhaseSetup, i+1, func() { nodes[i] = pki.NewLocalParty(i+1, config, curve, r) })
+	}
+	publicNodes := lo.Map(nodes, func(node pki.LocalParty, _ int) pki.PublicParty { return node.PublicParty })
+	talliers := lo.Map(sample(len(nodes), s.participation, r), func(i int, _ int) pki.DkgParty {
+		return nodes[i].ToDkgParty(pki.UniformSelector{}.Select(nodes[i].PublicParty, publicNodes, config.GuardiansSize, r))
+	})
+	sort.Slice(talliers, func(i, j int) bool { return talliers[i].Index < talliers[j].Index })
+	if len(talliers) == 0 {
+		return errors.New("no party takes part in the DKG")
+	}
+
+	// distribution: broadcast the contribution, send one share per guardian
+	contributions := make(map[int]pki.DkgContribution)
+	received := make(election.PartyIndexToShares)
+	for _, tallier := range talliers {
+		var dealt election.PartyIndexToShares
+		meter.Measure(PhaseDistribution, tallier.Index, func() {
+			contributions[tallier.Index] = tallier.Contribution(curve)
+			dealt = election.DistributeShares([]pki.DkgParty{tallier}, curve)
+		})
+		meter.Send(PhaseDistribution, tallier.Index, cost.EncodeContribution(contributions[tallier.Index], curve))
+		for _, guardian := range sortedKeys(dealt) {
+			for _, share := range dealt[guardian] {
+				meter.Send(PhaseDistribution, tallier.Index, cost.EncodeShare(share))
+				received[guardian] = append(received[guardian], share)
+			}
+		}
+	}
+	for _, node := range nodes {
+		var invalid []int
+		meter.Measure(PhaseDistribution, node.Index, func() {
+			for _, share := range received[node.Index] {
+				if !contributions[share.From].VerifyShare(share, curve) {
+					invalid = append(invalid, share.From)
+				}
+			}
+		})
+		if len(invalid) > 0 {
+			return fmt.Errorf("party %d received invalid shares from %v", node.Index, invalid)
+		}
+	}
+	encryptionKey := election.VotingPublicKey(talliers, curve)
+
+	// voting: every party posts a ballot
+	ballots := make([]common.EncryptedBallot, len(nodes))
+	for i, node := range nodes {
+		meter.Measure(PhaseVoting, node.Index, func() {
+			ballots[i] = election.Voting([]pki.LocalParty{node}, encryptionKey, curve, r)[0]
+		})
+		meter.Send(PhaseVoting, node.Index, cost.EncodeBallot(node.Index, ballots[i], curve))
+	}
+	var accumulator *elgamal.Accumulator
+	var C1 common.Point
+	meter.Measure(PhaseTally, cost.Aggregator, func() {
+		accumulator = election.Accumulate(ballots, config, encryptionKey, curve)
+		C1 = accumulator.Sum().C1
+	})
+
+	// tally: returning talliers publish proven partial decryptions
+	online := make(map[int]bool)
+	for _, i := range sample(len(talliers), s.retention, r) {
+		online[talliers[i].Index] = true
+	}
+	isTallier := lo.SliceToMap(talliers, func(t pki.DkgParty) (int, bool) { return t.Index, true })
+	others := lo.Filter(nodes, func(node pki.LocalParty, _ int) bool { return !isTallier[node.Index] })
+	available := lo.Assign(online)
+	for _, i := range sample(len(others), s.newGuardians, r) {
+		available[others[i].Index] = true
+	}
+
+	shares := make(map[int][]pki.DecryptionShare)
+	absent := make(map[int]bool)
+	for _, tallier := range talliers {
+		if !online[tallier.Index] {
+			absent[tallier.Index] = true
+			continue
+		}
+		var share pki.DecryptionShare
+		meter.Measure(PhaseTally, tallier.Index, func() { share = tallier.PartialDecryption(C1, curve) })
+		meter.Send(PhaseTally, tallier.Index, cost.EncodeDecryptionShare(tallier.Index, share, curve))
+		shares[tallier.Index] = []pki.DecryptionShare{share}
+	}
+
+	// reconstruction: available guardians of absent talliers publish proven
+	// partial decryptions of their shares
+	for _, guardian := range sortedKeys(available) {
+		if !lo.ContainsBy(received[guardian], func(share sss.Share) bool { return absent[share.From] }) {
+			continue
+		}
+		var decryptions map[int]pki.DecryptionShare
+		meter.Measure(PhaseReconstruction, guardian, func() {
+			decryptions = election.GuardianDecryptions(guardian, received, absent, C1, config.ElectionID(), curve)
+		})
+		for _, tallier := range sortedKeys(decryptions) {
+			meter.Send(PhaseReconstruction, guardian, cost.EncodeDecryptionShare(tallier, decryptions[tallier], curve))
+			shares[tallier] = append(shares[tallier], decryptions[tallier])
+		}
+	}
+
+	// decryption: check and combine the decryption shares and decode the tally
+	var results []int
+	var err error
+	meter.Measure(PhaseDecryption, cost.Aggregator, func() {
+		results, err = election.Decrypt(accumulator, lo.Map(talliers, func(t pki.DkgParty, _ int) pki.DkgContribution {
+			return contributions[t.Index]
+		}), shares, curve)
+	})
+	if err != nil {
+		return err
+	}
+	if config.Options == 2 && results[0] != lo.CountBy(nodes, func(node pki.LocalParty) bool { return node.Vote() == 1 }) {
+		return errWrongTally
+	}
+	return nil
+}
+
+func main() {
+	size := flag.Int("size", 100, "number of parties")
+	options := flag.Int("options", 2, "number of voting options, at most 4")
+	threshold := flag.Int("threshold", 20, "threshold t of every guardian set")
+	guardians := flag.Int("guardians", 40, "guardian set size k")
+	participation := flag.Float64("participation", 0.5, "fraction of parties taking part in the DKG")
+	retention := flag.Float64("retention", 0.8, "fraction of DKG parties returning to tally")
+	newGuardians := flag.Float64("new", 1, "fraction of non-DKG parties available as guardians in the tally")
+	seed := flag.Int64("seed", 0, "seed of the election")
+	out := flag.String("out", "", "output JSON file, defaults to stdout")
+	flag.Parse()
+
+	s := setting{
+		config: common.VotingConfig{
+			Size:          *size,
+			Options:       *options,
+			Threshold:     *threshold,
+			GuardiansSize: *guardians,
+		},
+		participation: *participation,
+		retention:     *retention,
+		newGuardians:  *newGuardians,
+	}
+	if err := s.config.ValidateGuardianSet(*threshold, *guardians); err != nil {
+		log.Fatal(err)
+	}
+
+	pki.Output = io.Discard
+	meter := cost.NewMeter(secp256k1.Curve)
+	if err := measureElection(s, meter, rand.New(rand.NewSource(*seed))); err != nil {
+		log.Printf("tally not decrypted: %v", err)
+	}
+
+	w := os.Stdout
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer file.Close()
+		w = file
+	}
+	if err := meter.Report(s.config).WriteJSON(w); err != nil {
+		log.Fatal(err)
+	}
+}
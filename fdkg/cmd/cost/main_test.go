@@ -0,0 +1,80 @@
+package main
+
+import (
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/delendum-xyz/private-voting/fdkg/common"
+	"github.com/delendum-xyz/private-voting/fdkg/cost"
+	"github.com/delendum-xyz/private-voting/fdkg/pki"
+	"github.com/torusresearch/pvss/secp256k1"
+)
+
+const (
+	indexSize    = 4
+	scalarSize   = 32
+	pointSize    = 33
+	decShareSize = 2*indexSize + pointSize + 2*scalarSize
+)
+
+func TestDistributionCostIsLinearInGuardians(t *testing.T) {
+	pki.Output = io.Discard
+	for _, k := range []int{2, 4, 8} {
+		s := setting{
+			config:        common.VotingConfig{Size: 10, Options: 2, Threshold: 2, GuardiansSize: k},
+			participation: 0.5,
+			retention:     1,
+		}
+		meter := cost.NewMeter(secp256k1.Curve)
+		if err := measureElection(s, meter, rand.New(rand.NewSource(0))); err != nil {
+			t.Fatal(err)
+		}
+		report := meter.Report(s.config)
+		n := 5
+		distribution := report.Phase(PhaseDistribution).Total
+		if distribution.Messages != n*(1+k) {
+			t.Errorf("k=%d: expected %d distribution messages, got %d", k, n*(1+k), distribution.Messages)
+		}
+		contributionSize := 3*indexSize + k*indexSize + 2*pointSize
+		shareSize := 2*indexSize + scalarSize
+		if distribution.Bytes != n*(contributionSize+k*shareSize) {
+			t.Errorf("k=%d: expected %d distribution bytes, got %d", k, n*(contributionSize+k*shareSize), distribution.Bytes)
+		}
+		if tally := report.Phase(PhaseTally).Total; tally.Messages != n || tally.Bytes != n*decShareSize {
+			t.Errorf("k=%d: expected %d tally messages, got %+v", k, n, tally)
+		}
+		if reconstruction := report.Phase(PhaseReconstruction).Total; reconstruction.Messages != 0 {
+			t.Errorf("k=%d: expected no reconstruction with every tallier back, got %+v", k, reconstruction)
+		}
+		if voting := report.Phase(PhaseVoting).Total; voting.Messages != 10 || voting.ScalarMults != 2*10 {
+			t.Errorf("k=%d: expected 10 ballots of 2 scalar multiplications each, got %+v", k, voting)
+		}
+	}
+}
+
+func TestReconstructionCost(t *testing.T) {
+	pki.Output = io.Discard
+	s := setting{
+		config:        common.VotingConfig{Size: 8, Options: 2, Threshold: 2, GuardiansSize: 3},
+		participation: 0.5,
+		retention:     0,
+		newGuardians:  1,
+	}
+	meter := cost.NewMeter(secp256k1.Curve)
+	if err := measureElection(s, meter, rand.New(rand.NewSource(0))); err != nil {
+		t.Fatal(err)
+	}
+	report := meter.Report(s.config)
+	// no tallier returns, only the 4 non-DKG parties answer as guardians
+	reconstruction := report.Phase(PhaseReconstruction)
+	if reconstruction.Total.Messages == 0 || reconstruction.Total.Bytes != reconstruction.Total.Messages*decShareSize {
+		t.Errorf("Expected proven guardian decryptions, got %+v", reconstruction.Total)
+	}
+	if reconstruction.Total.Messages > 4*3 {
+		t.Errorf("Expected at most one message per guardian and tallier, got %d", reconstruction.Total.Messages)
+	}
+	if report.Phase(PhaseTally).Total.Messages != 0 {
+		t.Errorf("Expected no tallier partial decryptions")
+	}
+}
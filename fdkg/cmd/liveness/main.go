@@ -0,0 +1,266 @@
+// Command liveness is a Monte-Carlo liveness simulator built on the election
+// code of fdkg/election. Unlike liveness_sim, every simulated election deals
+// actual shares, encrypts ballots and reconstructs the proven partial
+// decryptions of absent talliers from their guardians, so the success rate
+// reflects the implementation rather than a model of it.
+//
+// Results are written as CSV in the same shape as liveness_sim's
+// full_simulation_results_nodes_*.csv.
+package main
+
+import (
+	"crypto/elliptic"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/delendum-xyz/private-voting/fdkg/common"
+	"github.com/delendum-xyz/private-voting/fdkg/election"
+	"github.com/delendum-xyz/private-voting/fdkg/pki"
+	"github.com/delendum-xyz/private-voting/fdkg/topology"
+	"github.com/delendum-xyz/private-voting/fdkg/utils"
+	"github.com/samber/lo"
+	"github.com/torusresearch/pvss/secp256k1"
+)
+
+var curve = secp256k1.Curve
+
+// topologies maps the -topology flag to the name used in the output file.
+var topologies = map[string]string{
+	"uniform": "Uniform",
+	"ba":      "BarabasiAlbert",
+	"er":      "ErdosRenyi",
+	"ws":      "WattsStrogatz",
+	"sw":      "SmallWorld",
+}
+
+type experiment struct {
+	topology      string
+	rewire        float64
+	nodes         int
+	guardians     int
+	threshold     int
+	fdkgPct       float64
+	tallierRetPct float64
+	tallierNewPct float64
+}
+
+// latticeDegree rounds the guardian set size up to the even degree a ring
+// lattice needs.
+func (e experiment) latticeDegree() int {
+	k := e.guardians + e.guardians%2
+	if k >= e.nodes {
+		k -= 2
+	}
+	return k
+}
+
+// guardianSelector builds a fresh trust graph for one election.
+func (e experiment) guardianSelector(r *rand.Rand) pki.GuardianSelector {
+	switch e.topology {
+	case "ba":
+		return topology.BarabasiAlbert(e.nodes, e.guardians, r).Selector()
+	case "er":
+		return topology.ErdosRenyi(e.nodes, float64(e.guardians)/float64(e.nodes-1), r).Selector()
+	case "ws":
+		return topology.WattsStrogatz(e.nodes, e.latticeDegree(), e.rewire, r).Selector()
+	case "sw":
+		return topology.SmallWorld(e.nodes, e.latticeDegree(), e.rewire, r).Selector()
+	default:
+		return pki.UniformSelector{}
+	}
+}
+
+func (e experiment) valid() bool {
+	return e.nodes > 1 && e.guardians >= 1 && e.guardians <= e.nodes-1 && e.threshold >= 1 && e.threshold <= e.guardians
+}
+
+// selectTalliers picks the parties that show up in the tally phase: a
+// tallierRetPct fraction of the DKG parties and a tallierNewPct fraction of
+// the others.
+func selectTalliers(localNodes []pki.LocalParty, dkgNodes []pki.DkgParty, e experiment, r *rand.Rand) map[int]bool {
+	isDkg := lo.SliceToMap(dkgNodes, func(node pki.DkgParty) (int, bool) { return node.Index, true })
+	fdkg := lo.Filter(localNodes, func(node pki.LocalParty, _ int) bool { return isDkg[node.Index] })
+	others := lo.Filter(localNodes, func(node pki.LocalParty, _ int) bool { return !isDkg[node.Index] })
+
+	talliers := make(map[int]bool)
+	sample := func(parties []pki.LocalParty, pct float64) {
+		n := int(math.Floor(float64(len(parties)) * pct))
+		for _, i := range r.Perm(len(parties))[:n] {
+			talliers[parties[i].Index] = true
+		}
+	}
+	sample(fdkg, e.tallierRetPct)
+	sample(others, e.tallierNewPct)
+	return talliers
+}
+
+// runElection simulates one election and reports whether the tally could be
+// decrypted to the expected result.
+func runElection(e experiment, curve elliptic.Curve, r *rand.Rand) bool {
+	config := common.VotingConfig{
+		Size:          e.nodes,
+		Options:       2,
+		Threshold:     e.threshold,
+		GuardiansSize: e.guardians,
+	}
+	nDkg := int(math.Floor(float64(e.nodes) * e.fdkgPct))
+	if nDkg == 0 {
+		return false
+	}
+	localNodes, dkgNodes, err := pki.GenerateSetOfNodes(config, nDkg, e.guardianSelector(r), curve, r)
+	if err != nil {
+		// a party without enough guardians can never be reconstructed
+		return false
+	}
+
+	// distribution phase
+	contributions := lo.Map(dkgNodes, func(node pki.DkgParty, _ int) pki.DkgContribution { return node.Contribution(curve) })
+	received := election.DistributeShares(dkgNodes, curve)
+	encryptionKey := election.VotingPublicKey(dkgNodes, curve)
+
+	// voting phase, every party votes
+	accumulator := election.Accumulate(election.Voting(localNodes, encryptionKey, curve, r), config, encryptionKey, curve)
+	expected := lo.CountBy(localNodes, func(node pki.LocalParty) bool { return node.Vote() == 1 })
+
+	// tally phase
+	talliers := selectTalliers(localNodes, dkgNodes, e, r)
+	C1 := accumulator.Sum().C1
+	shares := election.DecryptionShares(dkgNodes, received, talliers, C1, config.ElectionID(), curve)
+	results, err := election.Decrypt(accumulator, contributions, shares, curve)
+	return err == nil && results[0] == expected
+}
+
+func successRate(e experiment, iterations int, seed int64) float64 {
+	successes := 0
+	for i := 0; i < iterations; i++ {
+		r := rand.New(rand.NewSource(seed + int64(i)))
+		if runElection(e, curve, r) {
+			successes++
+		}
+	}
+	return float64(successes) / float64(iterations)
+}
+
+func parseInts(s string) ([]int, error) {
+	return parseList(s, strconv.Atoi)
+}
+
+func parseFloats(s string) ([]float64, error) {
+	return parseList(s, func(v string) (float64, error) { return strconv.ParseFloat(v, 64) })
+}
+
+func parseList[T any](s string, parse func(string) (T, error)) ([]T, error) {
+	values := make([]T, 0)
+	for _, field := range strings.Split(s, ",") {
+		v, err := parse(strings.TrimSpace(field))
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func main() {
+	nodesFlag := flag.String("nodes", "50", "comma separated numbers of parties")
+	guardiansFlag := flag.String("guardians", "5", "comma separated guardian set sizes")
+	thresholdsFlag := flag.String("thresholds", "1,2,3,4,5", "comma separated thresholds")
+	participationFlag := flag.String("participation", "0.5,0.8,1", "comma separated fractions of parties taking part in the DKG")
+	retentionFlag := flag.String("retention", "0.5,0.7,0.9,1", "comma separated fractions of DKG parties returning as talliers")
+	newFlag := flag.String("new", "0", "comma separated fractions of non-DKG parties joining as talliers")
+	iterations := flag.Int("iterations", 100, "elections simulated per configuration")
+	seed := flag.Int64("seed", 0, "seed of the first election, election i uses seed+i")
+	topologyFlag := flag.String("topology", "uniform", "trust graph guardians are chosen from: uniform, ba, er, ws or sw")
+	rewire := flag.Float64("rewire", 0.1, "rewiring (ws) or shortcut (sw) probability")
+	out := flag.String("out", "", "output CSV file, defaults to full_simulation_results_nodes_<topology>.csv")
+	flag.IntVar(&utils.Workers, "workers", utils.Workers, "goroutines dealing shares and searching the tally, results do not depend on it")
+	flag.Parse()
+
+	topologyName, ok := topologies[*topologyFlag]
+	if !ok {
+		log.Fatalf("invalid -topology: %s", *topologyFlag)
+	}
+	if *out == "" {
+		*out = fmt.Sprintf("full_simulation_results_nodes_%s.csv", topologyName)
+	}
+
+	nodes, err := parseInts(*nodesFlag)
+	if err != nil {
+		log.Fatalf("invalid -nodes: %v", err)
+	}
+	guardians, err := parseInts(*guardiansFlag)
+	if err != nil {
+		log.Fatalf("invalid -guardians: %v", err)
+	}
+	thresholds, err := parseInts(*thresholdsFlag)
+	if err != nil {
+		log.Fatalf("invalid -thresholds: %v", err)
+	}
+	participation, err := parseFloats(*participationFlag)
+	if err != nil {
+		log.Fatalf("invalid -participation: %v", err)
+	}
+	retention, err := parseFloats(*retentionFlag)
+	if err != nil {
+		log.Fatalf("invalid -retention: %v", err)
+	}
+	newTalliers, err := parseFloats(*newFlag)
+	if err != nil {
+		log.Fatalf("invalid -new: %v", err)
+	}
+
+	file, err := os.Create(*out)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+	w := csv.NewWriter(file)
+	if err := w.Write([]string{"nodes", "guardians", "threshold", "fdkgPercentage", "tallierRetPct", "tallierNewPct", "successRate"}); err != nil {
+		log.Fatal(err)
+	}
+
+	pki.Output = io.Discard
+	for _, n := range nodes {
+		for _, k := range guardians {
+			for _, t := range thresholds {
+				for _, fdkgPct := range participation {
+					for _, retPct := range retention {
+						for _, newPct := range newTalliers {
+							e := experiment{topology: *topologyFlag, rewire: *rewire, nodes: n, guardians: k, threshold: t, fdkgPct: fdkgPct, tallierRetPct: retPct, tallierNewPct: newPct}
+							if !e.valid() {
+								continue
+							}
+							rate := successRate(e, *iterations, *seed)
+							fmt.Printf("nodes=%d guardians=%d threshold=%d fdkg=%v ret=%v new=%v success=%v\n", n, k, t, fdkgPct, retPct, newPct, rate)
+							err := w.Write([]string{
+								strconv.Itoa(n), strconv.Itoa(k), strconv.Itoa(t),
+								formatFloat(fdkgPct), formatFloat(retPct), formatFloat(newPct), formatFloat(rate),
+							})
+							if err != nil {
+								log.Fatal(err)
+							}
+						}
+					}
+				}
+			}
+		}
+		w.Flush()
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Results saved to %s\n", *out)
+}
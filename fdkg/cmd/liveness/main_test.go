@@ -0,0 +1,49 @@
+package main
+
+import (
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/delendum-xyz/private-voting/fdkg/pki"
+)
+
+func TestFullRetentionAlwaysSucceeds(t *testing.T) {
+	pki.Output = io.Discard
+	e := experiment{nodes: 10, guardians: 3, threshold: 2, fdkgPct: 0.8, tallierRetPct: 1, tallierNewPct: 0}
+	for i := 0; i < 10; i++ {
+		if !runElection(e, curve, rand.New(rand.NewSource(int64(i)))) {
+			t.Errorf("[%v] election with every tallier present failed", i)
+		}
+	}
+}
+
+func TestReconstructionFromGuardians(t *testing.T) {
+	pki.Output = io.Discard
+	// no DKG party returns, so every partial decryption has to be
+	// reconstructed from the non-DKG parties that show up as guardians
+	e := experiment{nodes: 10, guardians: 3, threshold: 1, fdkgPct: 0.3, tallierRetPct: 0, tallierNewPct: 1}
+	for i := 0; i < 10; i++ {
+		if !runElection(e, curve, rand.New(rand.NewSource(int64(i)))) {
+			t.Errorf("[%v] reconstruction from guardians failed", i)
+		}
+	}
+}
+
+func TestNoTalliersFails(t *testing.T) {
+	pki.Output = io.Discard
+	e := experiment{nodes: 10, guardians: 3, threshold: 1, fdkgPct: 0.5, tallierRetPct: 0, tallierNewPct: 0}
+	if rate := successRate(e, 5, 0); rate != 0 {
+		t.Errorf("Expected every election to fail without talliers, got success rate %v", rate)
+	}
+}
+
+func TestTopologies(t *testing.T) {
+	pki.Output = io.Discard
+	for name := range topologies {
+		e := experiment{topology: name, rewire: 0.2, nodes: 12, guardians: 3, threshold: 2, fdkgPct: 0.5, tallierRetPct: 1, tallierNewPct: 0}
+		if !runElection(e, curve, rand.New(rand.NewSource(0))) {
+			t.Errorf("%s: election with every tallier present failed", name)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+// Command replay feeds a recorded transcript into a fresh bulletin board,
+// prints every message the board rejects and tallies the accepted ones. It
+// exits with status 1 if the tally differs from the result announced in the
+// transcript.
+//
+//	go run ./cmd/replay -transcript election.jsonl
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"reflect"
+
+	"github.com/delendum-xyz/private-voting/fdkg/board"
+	"github.com/torusresearch/pvss/secp256k1"
+)
+
+// replay writes the replay report to w and reports whether the tally matches
+// the announced result, if there is one.
+func replay(transcript io.Reader, w io.Writer) (bool, error) {
+	b, err := board.Replay(transcript, secp256k1.Curve)
+	if err != nil {
+		return false, err
+	}
+	fmt.Fprintf(w, "contributions: %d, ballots: %d, rejected: %d\n", len(b.Contributions()), len(b.Ballots()), len(b.Rejected))
+	for _, rejection := range b.Rejected {
+		fmt.Fprintf(w, "rejected #%d: %v\n", rejection.Seq, rejection.Err)
+	}
+	result, err := b.Tally()
+	if err != nil {
+		return false, err
+	}
+	fmt.Fprintf(w, "tally: %v\n", result)
+	if b.Announced == nil {
+		return true, nil
+	}
+	fmt.Fprintf(w, "announced: %v\n", b.Announced.Tally)
+	return reflect.DeepEqual(result, b.Announced.Tally), nil
+}
+
+func main() {
+	path := flag.String("transcript", "", "transcript to replay")
+	flag.Parse()
+	if *path == "" {
+		log.Fatal("missing -transcript")
+	}
+
+	file, err := os.Open(*path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+	matches, err := replay(file, os.Stdout)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if !matches {
+		fmt.Println("tally differs from the announced result")
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/delendum-xyz/private-voting/fdkg/board"
+)
+
+func TestReplayFixture(t *testing.T) {
+	data, err := os.ReadFile("../../board/testdata/election.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	matches, err := replay(strings.NewReader(string(data)), io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !matches {
+		t.Errorf("Expected the replayed tally to match the announced result")
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	var result board.Entry
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &result); err != nil {
+		t.Fatal(err)
+	}
+	result.Payload = json.RawMessage(`{"tally":[0]}`)
+	tampered, err := json.Marshal(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines[len(lines)-1] = string(tampered)
+	matches, err = replay(strings.NewReader(strings.Join(lines, "\n")), io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if matches {
+		t.Errorf("Expected a wrong announced result to be reported")
+	}
+}
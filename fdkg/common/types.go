@@ -2,7 +2,10 @@ package common
 
 import (
 	"crypto/elliptic"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"math/big"
 )
 
@@ -36,6 +39,10 @@ func (p Point) IsOnCurve(curve elliptic.Curve) bool {
 	return curve.IsOnCurve(&p.X, &p.Y)
 }
 
+func (p Point) Equal(q Point) bool {
+	return p.X.Cmp(&q.X) == 0 && p.Y.Cmp(&q.Y) == 0
+}
+
 var ErrInvalidPoint = errors.New("marshaled point was invalid")
 
 func (p *Point) Marshal(curve elliptic.Curve) []byte {
@@ -53,11 +60,62 @@ type Node struct {
 	PubKey Point
 }
 
+// VotingConfig describes an election. Threshold and GuardiansSize are the
+// defaults for parties that do not choose their own (t_i, k_i); the Min/Max
+// fields bound that choice election-wide, zero meaning no bound.
 type VotingConfig struct {
+	// Name tells apart elections run with otherwise identical parameters.
+	Name string
+
 	Size          int
 	Options       int
 	Threshold     int
 	GuardiansSize int
+
+	MinThreshold     int
+	MaxThreshold     int
+	MinGuardiansSize int
+	MaxGuardiansSize int
+
+	// EligibilityRoot is the Merkle root of the eligible voters' public keys;
+	// the zero hash lets anyone vote.
+	EligibilityRoot Hash
+}
+
+// ElectionID identifies the election like bytes32 eid in FDKGVoteGW: it is the
+// hash of the parameters, so artifacts of one election are rejected by every
+// other. The EligibilityRoot is left out, as the registry is only final once
+// the voters have registered, possibly after the talliers set up.
+func (c VotingConfig) ElectionID() Hash {
+	c.EligibilityRoot = Hash{}
+	encoded, err := json.Marshal(c)
+	if err != nil {
+		panic(err)
+	}
+	var id Hash
+	copy(id[:], Keccak256([]byte("fdkg/election"), encoded))
+	return id
+}
+
+var (
+	ErrGuardianSetOutOfBounds = errors.New("threshold or guardian set size out of election bounds")
+	ErrWrongElection          = errors.New("bound to another election")
+)
+
+// ValidateGuardianSet checks a party's own threshold t_i and guardian set size
+// k_i against the election bounds.
+func (c VotingConfig) ValidateGuardianSet(threshold, guardiansSize int) error {
+	switch {
+	case threshold < 1 || threshold > guardiansSize:
+		return fmt.Errorf("%w: need 1 <= t_i <= k_i, got t_i=%d k_i=%d", ErrGuardianSetOutOfBounds, threshold, guardiansSize)
+	case threshold < c.MinThreshold || (c.MaxThreshold > 0 && threshold > c.MaxThreshold):
+		return fmt.Errorf("%w: t_i=%d not in [%d, %d]", ErrGuardianSetOutOfBounds, threshold, c.MinThreshold, c.MaxThreshold)
+	case guardiansSize < c.MinGuardiansSize || (c.MaxGuardiansSize > 0 && guardiansSize > c.MaxGuardiansSize):
+		return fmt.Errorf("%w: k_i=%d not in [%d, %d]", ErrGuardianSetOutOfBounds, guardiansSize, c.MinGuardiansSize, c.MaxGuardiansSize)
+	case guardiansSize > c.Size-1:
+		return fmt.Errorf("%w: k_i=%d but only %d other parties", ErrGuardianSetOutOfBounds, guardiansSize, c.Size-1)
+	}
+	return nil
 }
 
 type EncryptedBallot struct {
@@ -65,6 +123,43 @@ type EncryptedBallot struct {
 	C2 Point
 }
 
+// Hash is a 32-byte digest, encoded as hex in JSON.
+type Hash [32]byte
+
+func (h Hash) String() string {
+	return hex.EncodeToString(h[:])
+}
+
+func (h Hash) IsZero() bool {
+	return h == Hash{}
+}
+
+func (h Hash) MarshalText() ([]byte, error) {
+	return []byte(h.String()), nil
+}
+
+func (h *Hash) UnmarshalText(text []byte) error {
+	decoded, err := hex.DecodeString(string(text))
+	if err != nil {
+		return err
+	}
+	if len(decoded) != len(h) {
+		return fmt.Errorf("hash must be %d bytes, got %d", len(h), len(decoded))
+	}
+	copy(h[:], decoded)
+	return nil
+}
+
+// Nullifier identifies the voter of a ballot within an election, like
+// nf_u = keccak256(sk_u || eid || "cast") in FDKGVoteGW. A voter always derives
+// the same nullifier, so a later ballot replaces an earlier one. Ballots are
+// published with the voter's public key, so it does not hide who voted.
+type Nullifier [32]byte
+
+func (n Nullifier) String() string {
+	return hex.EncodeToString(n[:])
+}
+
 type PartialDecryption struct {
 	Index int
 	Value Point
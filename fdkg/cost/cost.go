@@ -0,0 +1,171 @@
+// Package cost measures what running the protocol costs: messages and their
+// serialized size, scalar multiplications and wall time, for each phase and
+// each party.
+package cost
+
+import (
+	"crypto/elliptic"
+	"encoding/json"
+	"io"
+	"math/big"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/delendum-xyz/private-voting/fdkg/common"
+)
+
+// Aggregator is the party index under which work done by anyone on public
+// data is recorded, e.g. combining partial decryptions and decoding the tally.
+const Aggregator = 0
+
+// CountingCurve counts the scalar multiplications done through it.
+type CountingCurve struct {
+	elliptic.Curve
+	scalarMults int64
+}
+
+func NewCountingCurve(curve elliptic.Curve) *CountingCurve {
+	return &CountingCurve{Curve: curve}
+}
+
+func (c *CountingCurve) ScalarMult(x, y *big.Int, k []byte) (*big.Int, *big.Int) {
+	atomic.AddInt64(&c.scalarMults, 1)
+	return c.Curve.ScalarMult(x, y, k)
+}
+
+func (c *CountingCurve) ScalarBaseMult(k []byte) (*big.Int, *big.Int) {
+	atomic.AddInt64(&c.scalarMults, 1)
+	return c.Curve.ScalarBaseMult(k)
+}
+
+// CountScalarMult counts a scalar multiplication done without ScalarMult,
+// such as one from a utils.FixedBase table.
+func (c *CountingCurve) CountScalarMult() {
+	atomic.AddInt64(&c.scalarMults, 1)
+}
+
+// ScalarMults is the number of scalar multiplications so far.
+func (c *CountingCurve) ScalarMults() int64 {
+	return atomic.LoadInt64(&c.scalarMults)
+}
+
+// Cost is what one party spent in one phase.
+type Cost struct {
+	Messages    int           `json:"messages"`
+	Bytes       int           `json:"bytes"`
+	ScalarMults int64         `json:"scalarMults"`
+	WallTime    time.Duration `json:"wallTimeNs"`
+}
+
+func (c *Cost) add(o Cost) {
+	c.Messages += o.Messages
+	c.Bytes += o.Bytes
+	c.ScalarMults += o.ScalarMults
+	c.WallTime += o.WallTime
+}
+
+// Meter records costs per phase and party. Work is attributed with Measure,
+// which must not be called concurrently as scalar multiplications are read
+// from the shared CountingCurve.
+type Meter struct {
+	Curve *CountingCurve
+
+	mu     sync.Mutex
+	phases []string
+	costs  map[string]map[int]*Cost
+}
+
+func NewMeter(curve elliptic.Curve) *Meter {
+	return &Meter{Curve: NewCountingCurve(curve), costs: make(map[string]map[int]*Cost)}
+}
+
+func (m *Meter) cost(phase string, party int) *Cost {
+	parties, ok := m.costs[phase]
+	if !ok {
+		parties = make(map[int]*Cost)
+		m.costs[phase] = parties
+		m.phases = append(m.phases, phase)
+	}
+	if _, ok := parties[party]; !ok {
+		parties[party] = &Cost{}
+	}
+	return parties[party]
+}
+
+// Send records one message of the given serialized payload sent by party.
+func (m *Meter) Send(phase string, party int, payload []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cost(phase, party).add(Cost{Messages: 1, Bytes: len(payload)})
+}
+
+// Measure runs f and charges its wall time and scalar multiplications to
+// party in phase.
+func (m *Meter) Measure(phase string, party int, f func()) {
+	before := m.Curve.ScalarMults()
+	start := time.Now()
+	f()
+	elapsed := time.Since(start)
+	mults := m.Curve.ScalarMults() - before
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cost(phase, party).add(Cost{ScalarMults: mults, WallTime: elapsed})
+}
+
+// PartyCost is one party's share of a phase.
+type PartyCost struct {
+	Party int `json:"party"`
+	Cost
+}
+
+// PhaseReport is the total and per-party cost of one phase.
+type PhaseReport struct {
+	Phase   string      `json:"phase"`
+	Total   Cost        `json:"total"`
+	Parties []PartyCost `json:"parties"`
+}
+
+// Report is the machine-readable result of a measured election.
+type Report struct {
+	Config common.VotingConfig `json:"config"`
+	Phases []PhaseReport       `json:"phases"`
+	Total  Cost                `json:"total"`
+}
+
+// Report summarises the recorded costs, phases in the order they first
+// appeared and parties by index.
+func (m *Meter) Report(config common.VotingConfig) Report {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	report := Report{Config: config}
+	for _, phase := range m.phases {
+		phaseReport := PhaseReport{Phase: phase}
+		for party, cost := range m.costs[phase] {
+			phaseReport.Parties = append(phaseReport.Parties, PartyCost{Party: party, Cost: *cost})
+			phaseReport.Total.add(*cost)
+		}
+		sort.Slice(phaseReport.Parties, func(i, j int) bool { return phaseReport.Parties[i].Party < phaseReport.Parties[j].Party })
+		report.Phases = append(report.Phases, phaseReport)
+		report.Total.add(phaseReport.Total)
+	}
+	return report
+}
+
+// Phase returns the report of the named phase, or a zero report.
+func (r Report) Phase(phase string) PhaseReport {
+	for _, p := range r.Phases {
+		if p.Phase == phase {
+			return p
+		}
+	}
+	return PhaseReport{Phase: phase}
+}
+
+func (r Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
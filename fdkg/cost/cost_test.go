@@ -0,0 +1,56 @@
+package cost
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/delendum-xyz/private-voting/fdkg/common"
+	"github.com/torusresearch/pvss/secp256k1"
+)
+
+func TestCountingCurve(t *testing.T) {
+	curve := NewCountingCurve(secp256k1.Curve)
+	X, Y := curve.ScalarBaseMult(big.NewInt(3).Bytes())
+	curve.ScalarMult(X, Y, big.NewInt(5).Bytes())
+	curve.Add(X, Y, X, Y)
+	if curve.ScalarMults() != 2 {
+		t.Errorf("Expected 2 scalar multiplications, got %d", curve.ScalarMults())
+	}
+}
+
+func TestMeterReport(t *testing.T) {
+	meter := NewMeter(secp256k1.Curve)
+	meter.Send("distribution", 2, make([]byte, 10))
+	meter.Send("distribution", 1, make([]byte, 5))
+	meter.Send("distribution", 1, make([]byte, 7))
+	meter.Measure("tally", Aggregator, func() { meter.Curve.ScalarBaseMult(big.NewInt(1).Bytes()) })
+
+	report := meter.Report(common.VotingConfig{Size: 2})
+	if len(report.Phases) != 2 || report.Phases[0].Phase != "distribution" || report.Phases[1].Phase != "tally" {
+		t.Fatalf("Expected phases in order of appearance, got %+v", report.Phases)
+	}
+	distribution := report.Phase("distribution")
+	if distribution.Total.Messages != 3 || distribution.Total.Bytes != 22 {
+		t.Errorf("Expected 3 messages of 22 bytes, got %+v", distribution.Total)
+	}
+	if distribution.Parties[0].Party != 1 || distribution.Parties[0].Messages != 2 || distribution.Parties[0].Bytes != 12 {
+		t.Errorf("Expected party 1 first with 2 messages of 12 bytes, got %+v", distribution.Parties[0])
+	}
+	if report.Total.ScalarMults != 1 || report.Phase("tally").Parties[0].ScalarMults != 1 {
+		t.Errorf("Expected one scalar multiplication in the tally, got %+v", report.Total)
+	}
+
+	var buf bytes.Buffer
+	if err := report.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var decoded Report
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Total != report.Total {
+		t.Errorf("JSON round trip changed the total: %+v vs %+v", decoded.Total, report.Total)
+	}
+}
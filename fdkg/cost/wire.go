@@ -0,0 +1,66 @@
+package cost
+
+import (
+	"crypto/elliptic"
+	"encoding/binary"
+	"math/big"
+
+	"github.com/delendum-xyz/private-voting/fdkg/common"
+	"github.com/delendum-xyz/private-voting/fdkg/pki"
+	"github.com/delendum-xyz/private-voting/fdkg/sss"
+)
+
+// The wire format used to size messages: indices are 4-byte big-endian,
+// scalars 32 bytes and points SEC1 compressed.
+
+const scalarSize = 32
+
+func appendIndex(b []byte, index int) []byte {
+	return binary.BigEndian.AppendUint32(b, uint32(index))
+}
+
+func appendScalar(b []byte, s *big.Int) []byte {
+	return append(b, s.FillBytes(make([]byte, scalarSize))...)
+}
+
+func appendPoint(b []byte, p common.Point, curve elliptic.Curve) []byte {
+	return append(b, elliptic.MarshalCompressed(curve, &p.X, &p.Y)...)
+}
+
+// EncodeContribution is the broadcast of a tallier's threshold, guardians and
+// commitments; the first commitment is its voting public key.
+func EncodeContribution(c pki.DkgContribution, curve elliptic.Curve) []byte {
+	b := appendIndex(nil, c.Index)
+	b = appendIndex(b, c.Threshold)
+	b = appendIndex(b, len(c.Guardians))
+	for _, guardian := range c.Guardians {
+		b = appendIndex(b, guardian)
+	}
+	for _, commitment := range c.Commitments {
+		b = appendPoint(b, commitment, curve)
+	}
+	return b
+}
+
+// EncodeShare is the message from a dealer to one of its guardians.
+func EncodeShare(s sss.Share) []byte {
+	b := appendIndex(nil, s.From)
+	b = appendIndex(b, s.To)
+	return appendScalar(b, &s.Value)
+}
+
+func EncodeBallot(voter int, ballot common.EncryptedBallot, curve elliptic.Curve) []byte {
+	b := appendIndex(nil, voter)
+	b = appendPoint(b, ballot.C1, curve)
+	return appendPoint(b, ballot.C2, curve)
+}
+
+// EncodeDecryptionShare is a proven partial decryption for the tallier dealer,
+// published either by the tallier itself or by one of its guardians.
+func EncodeDecryptionShare(dealer int, d pki.DecryptionShare, curve elliptic.Curve) []byte {
+	b := appendIndex(nil, dealer)
+	b = appendIndex(b, d.Index)
+	b = appendPoint(b, d.Value, curve)
+	b = appendScalar(b, d.Proof.C)
+	return appendScalar(b, d.Proof.Z)
+}
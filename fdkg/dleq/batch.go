@@ -0,0 +1,113 @@
+package dleq
+
+import (
+	"crypto/elliptic"
+	"encoding/binary"
+	"math/big"
+	"sort"
+
+	"github.com/delendum-xyz/private-voting/fdkg/common"
+	"github.com/delendum-xyz/private-voting/fdkg/utils"
+)
+
+// batchWeightSize is the size in bytes of the random weights: a batch
+// with an invalid proof passes with probability 2^-128.
+const batchWeightSize = 16
+
+// BatchVerify verifies many proofs at once and returns the indices of the
+// invalid ones, or nil if all verify. It checks
+//
+//	sum of r_i*(Z_i*G1_i + C_i*H1_i - A_i) + s_i*(Z_i*G2_i + C_i*H2_i - B_i) == 0
+//
+// for random weights r_i, s_i with a single multi-scalar multiplication,
+// merging the terms of a shared base such as G. If the sum is not zero, the
+// proofs are checked one by one to find the failing ones. Proofs without
+// their commitments A and B, e.g. restored from (C, Z), or whose A and B do
+// not hash to C are checked one by one, so a proof passes in a batch exactly
+// when it passes alone.
+func BatchVerify(statements []DLEQ, proofs []DLEQProof, curve elliptic.Curve) []int {
+	if len(statements) != len(proofs) {
+		panic("statements and proofs differ in length")
+	}
+	var invalid, batched []int
+	for i := range proofs {
+		pr := &proofs[i]
+		switch {
+		case pr.Z == nil || pr.C == nil:
+			invalid = append(invalid, i)
+		case pr.A == nil || pr.B == nil || !pr.A.IsOnCurve(curve) || !pr.B.IsOnCurve(curve) ||
+			!pr.hasChallenge(challenge(pr.hash, statements[i], &pr.A.X, &pr.A.Y, &pr.B.X, &pr.B.Y, curve), curve):
+			if !pr.Verify(statements[i], curve) {
+				invalid = append(invalid, i)
+			}
+		default:
+			batched = append(batched, i)
+		}
+	}
+
+	weights := batchWeights(statements, proofs, batched, curve)
+	N := curve.Params().N
+	terms := map[string]*big.Int{}
+	points := map[string]common.Point{}
+	term := func(k *big.Int, p *common.Point) {
+		key := string(p.Marshal(curve))
+		if _, ok := terms[key]; !ok {
+			terms[key], points[key] = new(big.Int), *p
+		}
+		terms[key].Add(terms[key], k).Mod(terms[key], N)
+	}
+	for j, i := range batched {
+		s, pr := statements[i], &proofs[i]
+		for side, w := range weights[2*j : 2*j+2] {
+			G, H, A := s.G1, s.H1, pr.A
+			if side == 1 {
+				G, H, A = s.G2, s.H2, pr.B
+			}
+			term(new(big.Int).Mul(w, pr.Z), G)
+			term(new(big.Int).Mul(w, pr.C), H)
+			term(new(big.Int).Neg(w), A)
+		}
+	}
+	keys := make([]string, 0, len(terms))
+	for key := range terms {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	scalars := make([]big.Int, len(keys))
+	bases := make([]common.Point, len(keys))
+	for j, key := range keys {
+		scalars[j], bases[j] = *terms[key], points[key]
+	}
+	if sum := utils.MultiScalarMult(scalars, bases, curve); sum.X.Sign() != 0 || sum.Y.Sign() != 0 {
+		for _, i := range batched {
+			if !proofs[i].Verify(statements[i], curve) {
+				invalid = append(invalid, i)
+			}
+		}
+	}
+	sort.Ints(invalid)
+	return invalid
+}
+
+// batchWeights derives the weights from a hash of the whole batch, so a
+// prover cannot choose proofs whose errors cancel out.
+func batchWeights(statements []DLEQ, proofs []DLEQProof, batched []int, curve elliptic.Curve) []*big.Int {
+	transcript := [][]byte{[]byte("fdkg/dleq/batch")}
+	for _, i := range batched {
+		s, pr := statements[i], proofs[i]
+		transcript = append(transcript, s.Context, s.G1.Marshal(curve), s.H1.Marshal(curve), s.G2.Marshal(curve), s.H2.Marshal(curve),
+			pr.A.Marshal(curve), pr.B.Marshal(curve), scalar(pr.C, curve), scalar(pr.Z, curve))
+	}
+	seed := utils.Keccak256(transcript...)
+	weights := make([]*big.Int, 2*len(batched))
+	for j := range weights {
+		index := make([]byte, 8)
+		binary.BigEndian.PutUint64(index, uint64(j))
+		weights[j] = new(big.Int).SetBytes(utils.Keccak256(seed, index)[:batchWeightSize])
+	}
+	return weights
+}
+
+func scalar(k *big.Int, curve elliptic.Curve) []byte {
+	return new(big.Int).Mod(k, curve.Params().N).FillBytes(make([]byte, (curve.Params().N.BitLen()+7)/8))
+}
@@ -12,10 +12,16 @@ import (
 type DLEQ struct {
 	G1, G2 *common.Point
 	H1, H2 *common.Point
+	// Context, if set, is hashed into the challenge so the proof only
+	// verifies for the same context, e.g. one election.
+	Context []byte
 }
 type DLEQProof struct {
 	Z *big.Int // response value
 	C *big.Int // hash of intermediate proof values to streamline equality checks
+	// A and B are the commitments w*G1 and w*G2. Verify recomputes them from
+	// (C, Z); BatchVerify needs them and checks proofs without them one by one.
+	A, B *common.Point
 
 	Curve elliptic.Curve
 	hash  crypto.Hash
@@ -31,31 +37,44 @@ func NewProof(w, a *big.Int, dleq DLEQ, hash crypto.Hash, curve elliptic.Curve)
 	// m and prevent existential forgery. What we care about here isn't
 	// committing to a particular m but the equality with the specific public
 	// key h.
-	H := hash.New()
-	H.Write(dleq.G1.Marshal(curve))
-	H.Write(dleq.H1.Marshal(curve))
-	H.Write(dleq.G2.Marshal(curve))
-	H.Write(dleq.H2.Marshal(curve))
-	H.Write(elliptic.Marshal(curve, Ax, Ay))
-	H.Write(elliptic.Marshal(curve, Bx, By))
-	cBytes := H.Sum(nil)
+	c := challenge(hash, dleq, Ax, Ay, Bx, By, curve)
 
 	// Expressing this as r = s - cx instead of r = s + cx saves us an
 	// inversion of c when calculating A and B on the verification side.
-	c := new(big.Int).SetBytes(cBytes)
-	c.Mod(c, curve.Params().N) // c = c (mod q)
 	r := new(big.Int).Neg(c)   // r = -c
 	r.Mul(r, a)                // r = -cx
 	r.Add(r, w)                // r = s - cx
 	r.Mod(r, curve.Params().N) // r = r (mod q)
 
+	A, B := common.BigIntToPoint(Ax, Ay), common.BigIntToPoint(Bx, By)
 	return DLEQProof{
 		Z: r, C: c,
+		A: &A, B: &B,
 		hash:  hash,
 		Curve: curve,
 	}
 }
 
+// challenge is c = H(context, g1, h1, g2, h2, a, b) mod N.
+func challenge(hash crypto.Hash, dleq DLEQ, Ax, Ay, Bx, By *big.Int, curve elliptic.Curve) *big.Int {
+	H := hash.New()
+	H.Write(dleq.Context)
+	H.Write(dleq.G1.Marshal(curve))
+	H.Write(dleq.H1.Marshal(curve))
+	H.Write(dleq.G2.Marshal(curve))
+	H.Write(dleq.H2.Marshal(curve))
+	H.Write(elliptic.Marshal(curve, Ax, Ay))
+	H.Write(elliptic.Marshal(curve, Bx, By))
+	c := new(big.Int).SetBytes(H.Sum(nil))
+	return c.Mod(c, curve.Params().N)
+}
+
+// RestoreProof rebuilds a proof from its published values, e.g. after
+// decoding it.
+func RestoreProof(z, c *big.Int, hash crypto.Hash, curve elliptic.Curve) DLEQProof {
+	return DLEQProof{Z: z, C: c, hash: hash, Curve: curve}
+}
+
 func (pr *DLEQProof) Verify(dleq DLEQ, curve elliptic.Curve) bool {
 	cHx, cHy := curve.ScalarMult(&dleq.H1.X, &dleq.H1.Y, pr.C.Bytes())
 	r1x, r1y := curve.ScalarMult(&dleq.G1.X, &dleq.G1.Y, pr.Z.Bytes())
@@ -68,14 +87,15 @@ func (pr *DLEQProof) Verify(dleq DLEQ, curve elliptic.Curve) bool {
 	A2x, A2y := curve.Add(r2x, r2y, cZx, cZy)
 
 	// C' = H(g, h, z, a, b) == C
-	H := pr.hash.New()
-	H.Write(dleq.G1.Marshal(curve))
-	H.Write(dleq.H1.Marshal(curve))
-	H.Write(dleq.G2.Marshal(curve))
-	H.Write(dleq.H2.Marshal(curve))
-	H.Write(elliptic.Marshal(curve, A1x, A1y))
-	H.Write(elliptic.Marshal(curve, A2x, A2y))
-	c := H.Sum(nil)
+	return pr.hasChallenge(challenge(pr.hash, dleq, A1x, A1y, A2x, A2y, curve), curve)
+}
 
-	return hmac.Equal(pr.C.Bytes(), c)
+// hasChallenge compares fixed-width encodings of the reduced challenges, as
+// NewProof reduces c and Bytes() drops leading zeros.
+func (pr *DLEQProof) hasChallenge(c *big.Int, curve elliptic.Curve) bool {
+	if pr.C.Sign() < 0 || pr.C.Cmp(curve.Params().N) >= 0 {
+		return false
+	}
+	size := (curve.Params().N.BitLen() + 7) / 8
+	return hmac.Equal(pr.C.FillBytes(make([]byte, size)), c.FillBytes(make([]byte, size)))
 }
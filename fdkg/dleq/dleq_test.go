@@ -6,6 +6,7 @@ import (
 	cryptoRand "crypto/rand"
 	_ "crypto/sha256"
 	"math/big"
+	"reflect"
 	"testing"
 
 	"github.com/delendum-xyz/private-voting/fdkg/common"
@@ -92,3 +93,45 @@ func TestInvalidProof(t *testing.T) {
 		t.Fatal("validated an invalid proof")
 	}
 }
+
+func TestBatchVerify(t *testing.T) {
+	curve := secp256k1.Curve
+	G := common.BigIntToPoint(curve.Params().Gx, curve.Params().Gy)
+	var statements []DLEQ
+	var proofs []DLEQProof
+	for i := 0; i < 20; i++ {
+		x, _, _, err := elliptic.GenerateKey(curve, cryptoRand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w, _, _, err := elliptic.GenerateKey(curve, cryptoRand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, Mx, My, err := elliptic.GenerateKey(curve, cryptoRand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		H := common.BigIntToPoint(curve.ScalarBaseMult(x))
+		M := common.BigIntToPoint(Mx, My)
+		Z := common.BigIntToPoint(curve.ScalarMult(Mx, My, x))
+		dleq := DLEQ{G1: &G, H1: &H, G2: &M, H2: &Z, Context: []byte("election")}
+		statements = append(statements, dleq)
+		proofs = append(proofs, NewProof(new(big.Int).SetBytes(w), new(big.Int).SetBytes(x), dleq, crypto.SHA256, curve))
+	}
+	if invalid := BatchVerify(statements, proofs, curve); invalid != nil {
+		t.Fatalf("Valid proofs %v rejected", invalid)
+	}
+
+	// a proof restored from (C, Z) is checked on its own
+	proofs[3] = RestoreProof(proofs[3].Z, proofs[3].C, crypto.SHA256, curve)
+	// a wrong response fails only the combined check
+	proofs[7].Z = new(big.Int).Add(proofs[7].Z, big.NewInt(1))
+	// a statement that does not match the challenge
+	statements[12].Context = []byte("other election")
+	// commitments that do not match a valid (C, Z) leave the proof valid
+	proofs[15].A, proofs[15].B = proofs[16].A, proofs[16].B
+	if invalid := BatchVerify(statements, proofs, curve); !reflect.DeepEqual(invalid, []int{7, 12}) {
+		t.Errorf("Expected proofs [7 12] to be rejected, got %v", invalid)
+	}
+}
@@ -0,0 +1,206 @@
+// Package election runs the phases of an election over a set of parties:
+// distributing the shares, voting and tallying, with or without talliers
+// whose partial decryptions have to be reconstructed from their guardians.
+package election
+
+import (
+	"crypto/elliptic"
+	"math/big"
+	"math/rand"
+	"sort"
+
+	"github.com/delendum-xyz/private-voting/fdkg/common"
+	"github.com/delendum-xyz/private-voting/fdkg/elgamal"
+	"github.com/delendum-xyz/private-voting/fdkg/pki"
+	"github.com/delendum-xyz/private-voting/fdkg/sss"
+	"github.com/delendum-xyz/private-voting/fdkg/utils"
+
+	"github.com/samber/lo"
+)
+
+func VotingPublicKey(dkgNodes []pki.DkgParty, curve elliptic.Curve) common.Point {
+	sum := dkgNodes[0].VotingPublicKey
+	for _, node := range dkgNodes[1:] {
+		pubKey := node.VotingPublicKey
+		X, Y := curve.Add(&sum.X, &sum.Y, &pubKey.X, &pubKey.Y)
+		sum.X, sum.Y = *X, *Y
+	}
+	return common.BigIntToPoint(&sum.X, &sum.Y)
+}
+
+func Voting(nodes []pki.LocalParty, encryptionKey common.Point, curve elliptic.Curve, r *rand.Rand) []common.EncryptedBallot {
+	tables := elgamal.NewTables(encryptionKey, curve)
+	return utils.Map(nodes, func(node pki.LocalParty) common.EncryptedBallot {
+		return node.EncryptedBallotWith(tables, r)
+	})
+}
+
+// LatestBallots keeps the last ballot cast under each nullifier, in the order
+// the nullifiers first appear, so a voter who votes again is counted once.
+// A cast is rejected, and its index returned, if its signature does not
+// verify, if its nullifier was first claimed by another key or if its key
+// already cast under another nullifier.
+func LatestBallots(casts []pki.CastBallot, election common.Hash, curve elliptic.Curve) ([]common.EncryptedBallot, []int) {
+	latest := make(map[common.Nullifier]int)
+	owners := make(map[common.Nullifier]string)
+	used := make(map[string]common.Nullifier)
+	var counted []common.EncryptedBallot
+	var rejected []int
+	for i, cast := range casts {
+		key := string(cast.PublicKey.Marshal(curve))
+		if owner, found := owners[cast.Nullifier]; found && owner != key {
+			rejected = append(rejected, i)
+			continue
+		}
+		if nullifier, found := used[key]; found && nullifier != cast.Nullifier {
+			rejected = append(rejected, i)
+			continue
+		}
+		if !cast.Verify(election, curve) {
+			rejected = append(rejected, i)
+			continue
+		}
+		owners[cast.Nullifier] = key
+		used[key] = cast.Nullifier
+		if j, found := latest[cast.Nullifier]; found {
+			counted[j] = cast.Ballot
+			continue
+		}
+		latest[cast.Nullifier] = len(counted)
+		counted = append(counted, cast.Ballot)
+	}
+	return counted, rejected
+}
+
+type PartyIndexToShares = map[int][]sss.Share
+type PartyIndexToVotingPrivKeyShare = map[int][]sss.Share
+
+// DistributeShares generates the shares of every DKG party, in parallel, and
+// groups them by the guardian receiving them in the order of dkgNodes.
+func DistributeShares(dkgNodes []pki.DkgParty, curve elliptic.Curve) PartyIndexToShares {
+	dealt := utils.ParallelMap(dkgNodes, func(node pki.DkgParty) []sss.Share {
+		return node.GenerateShares(curve)
+	})
+	partyIndexToShares := make(PartyIndexToShares)
+	for _, shares := range dealt {
+		for _, share := range shares {
+			partyIndexToShares[share.To] = append(partyIndexToShares[share.To], share)
+		}
+	}
+	return partyIndexToShares
+}
+
+func sortedKeys[T any](m map[int]T) []int {
+	keys := lo.Keys(m)
+	sort.Ints(keys)
+	return keys
+}
+
+func PartyToVotingPrivKeyShare(shares PartyIndexToShares) map[int]big.Int {
+	parties := sortedKeys(shares)
+	votingPrivKeyShares := utils.ParallelMap(parties, func(party int) big.Int {
+		sharesTimesCoefficients := utils.Map(shares[party], func(share sss.Share) big.Int {
+			return share.ProductOfShareAndCoefficient().Value
+		})
+
+		votingPrivKeyShare := lo.Reduce(sharesTimesCoefficients, func(agg *big.Int, item big.Int, i int) *big.Int {
+			return agg.Add(agg, &item)
+		}, big.NewInt(0))
+		return *votingPrivKeyShare
+	})
+	partyToVotingPrivKeyShare := make(map[int]big.Int)
+	for i, party := range parties {
+		partyToVotingPrivKeyShare[party] = votingPrivKeyShares[i]
+	}
+	return partyToVotingPrivKeyShare
+}
+
+type PartialDecryptions = []common.Point
+
+// Accumulate adds the votes to a fresh accumulator, without checking them.
+func Accumulate(votes []common.EncryptedBallot, config common.VotingConfig, encryptionKey common.Point, curve elliptic.Curve) *elgamal.Accumulator {
+	accumulator := elgamal.NewAccumulator(config.Options, config.ElectionID(), encryptionKey, curve)
+	for _, vote := range votes {
+		accumulator.Add(vote)
+	}
+	return accumulator
+}
+
+func OnlineTally(votes []common.EncryptedBallot, shares PartyIndexToShares, curve elliptic.Curve) PartialDecryptions {
+	return OnlineTallyOf(Accumulate(votes, common.VotingConfig{}, common.Point{}, curve), shares, curve)
+}
+
+// OnlineTallyOf partially decrypts the sum of the accumulated ballots.
+func OnlineTallyOf(accumulator *elgamal.Accumulator, shares PartyIndexToShares, curve elliptic.Curve) PartialDecryptions {
+	C1 := accumulator.Sum().C1
+	partyToVotingPrivKeyShare := PartyToVotingPrivKeyShare(shares)
+
+	Zs := utils.ParallelMap(sortedKeys(partyToVotingPrivKeyShare), func(index int) common.Point {
+		votingPrivKeyShare := partyToVotingPrivKeyShare[index]
+		return common.BigIntToPoint(curve.ScalarMult(&C1.X, &C1.Y, votingPrivKeyShare.Bytes()))
+	})
+	return Zs
+}
+
+func OfflineTally(votes []common.EncryptedBallot, partialDecryptions PartialDecryptions, config common.VotingConfig, curve elliptic.Curve) []int {
+	return OfflineTallyOf(Accumulate(votes, config, common.Point{}, curve), partialDecryptions, curve)
+}
+
+// OfflineTallyOf decodes the accumulated ballots from the partial decryptions
+// of their C1 sum; its cost does not depend on the number of ballots.
+func OfflineTallyOf(accumulator *elgamal.Accumulator, partialDecryptions PartialDecryptions, curve elliptic.Curve) []int {
+	Z := lo.Reduce(partialDecryptions, func(p1, p2 common.Point, _ int) common.Point {
+		return common.BigIntToPoint(curve.Add(&p1.X, &p1.Y, &p2.X, &p2.Y))
+	}, common.PointZero())
+	return accumulator.Tally(Z)
+}
+
+// GuardianDecryptions are the proven partial decryptions of C1 that guardian
+// publishes for the absent talliers, one for each share it received from
+// them, keyed by the tallier.
+func GuardianDecryptions(guardian int, received PartyIndexToShares, absent map[int]bool, C1 common.Point, election common.Hash, curve elliptic.Curve) map[int]pki.DecryptionShare {
+	shares := make(map[int]pki.DecryptionShare)
+	for _, share := range received[guardian] {
+		if absent[share.From] {
+			shares[share.From] = pki.ProveDecryptionShare(guardian, share.Value, C1, election, curve)
+		}
+	}
+	return shares
+}
+
+// DecryptionShares collects the proven partial decryptions of C1 published
+// by the parties present in the tally, keyed by the tallier they decrypt
+// for: every present tallier's own and, for an absent tallier, those of its
+// present guardians.
+func DecryptionShares(talliers []pki.DkgParty, received PartyIndexToShares, present map[int]bool, C1 common.Point, election common.Hash, curve elliptic.Curve) map[int][]pki.DecryptionShare {
+	shares := make(map[int][]pki.DecryptionShare)
+	absent := make(map[int]bool)
+	for _, tallier := range talliers {
+		if !present[tallier.Index] {
+			absent[tallier.Index] = true
+			continue
+		}
+		shares[tallier.Index] = append(shares[tallier.Index], tallier.PartialDecryption(C1, curve))
+	}
+	for _, guardian := range sortedKeys(received) {
+		if !present[guardian] {
+			continue
+		}
+		decryptions := GuardianDecryptions(guardian, received, absent, C1, election, curve)
+		for _, tallier := range sortedKeys(decryptions) {
+			shares[tallier] = append(shares[tallier], decryptions[tallier])
+		}
+	}
+	return shares
+}
+
+// Decrypt checks the decryption shares of the accumulated ballots against
+// the talliers' contributions, reconstructs the partial decryptions of the
+// absent talliers and decodes the tally.
+func Decrypt(accumulator *elgamal.Accumulator, contributions []pki.DkgContribution, shares map[int][]pki.DecryptionShare, curve elliptic.Curve) ([]int, error) {
+	Z, err := pki.CombineDecryptionShares(accumulator.Sum().C1, contributions, shares, curve)
+	if err != nil {
+		return nil, err
+	}
+	return accumulator.Tally(Z), nil
+}
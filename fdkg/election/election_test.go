@@ -0,0 +1,61 @@
+package election
+
+import (
+	"errors"
+	"io"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/delendum-xyz/private-voting/fdkg/common"
+	"github.com/delendum-xyz/private-voting/fdkg/pki"
+	"github.com/samber/lo"
+	"github.com/torusresearch/pvss/secp256k1"
+)
+
+var curve = secp256k1.Curve
+
+func TestDecryptWithAbsentTallier(t *testing.T) {
+	pki.Output = io.Discard
+	config := common.VotingConfig{
+		Size:          6,
+		Options:       2,
+		Threshold:     2,
+		GuardiansSize: 3,
+	}
+	r := rand.New(rand.NewSource(0))
+	localNodes, dkgNodes, err := pki.GenerateSetOfNodes(config, 3, pki.UniformSelector{}, curve, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	contributions := lo.Map(dkgNodes, func(p pki.DkgParty, _ int) pki.DkgContribution { return p.Contribution(curve) })
+	received := DistributeShares(dkgNodes, curve)
+	encryptionKey := VotingPublicKey(dkgNodes, curve)
+	accumulator := Accumulate(Voting(localNodes, encryptionKey, curve, r), config, encryptionKey, curve)
+	C1 := accumulator.Sum().C1
+	expected := []int{lo.CountBy(localNodes, func(node pki.LocalParty) bool { return node.Vote() == 1 })}
+
+	// the first tallier is absent and its guardians decrypt for it
+	present := lo.SliceToMap(localNodes[1:], func(node pki.LocalParty) (int, bool) { return node.Index, true })
+	delete(present, dkgNodes[0].Index)
+	shares := DecryptionShares(dkgNodes, received, present, C1, config.ElectionID(), curve)
+	if len(shares[dkgNodes[0].Index]) == 0 {
+		t.Fatalf("Expected guardian decryptions for the absent tallier")
+	}
+	results, err := Decrypt(accumulator, contributions, shares, curve)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(results, expected) {
+		t.Errorf("Expected tally %v, got %v", expected, results)
+	}
+	if online := OfflineTallyOf(accumulator, OnlineTallyOf(accumulator, received, curve), curve); !reflect.DeepEqual(online, expected) {
+		t.Errorf("Expected the online tally %v, got %v", expected, online)
+	}
+
+	// nobody is left to decrypt
+	shares = DecryptionShares(dkgNodes, received, map[int]bool{}, C1, config.ElectionID(), curve)
+	if _, err := Decrypt(accumulator, contributions, shares, curve); !errors.Is(err, pki.ErrNotEnoughShares) {
+		t.Errorf("Expected ErrNotEnoughShares, got %v", err)
+	}
+}
@@ -0,0 +1,198 @@
+package elgamal
+
+import (
+	"crypto/elliptic"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/delendum-xyz/private-voting/fdkg/common"
+	"github.com/delendum-xyz/private-voting/fdkg/utils"
+	"github.com/samber/lo"
+)
+
+var ErrInvalidBallot = errors.New("invalid ballot")
+
+// jacobian is a point (X/Z^2, Y/Z^3); Z = 0 is the point at infinity.
+type jacobian struct {
+	X, Y, Z big.Int
+}
+
+// add adds the affine point q in place. Mixed addition needs no inversion and
+// does not depend on the curve's a coefficient; only the rare doubling falls
+// back to the curve.
+func (p *jacobian) add(q common.Point, curve elliptic.Curve) {
+	if q.X.Sign() == 0 && q.Y.Sign() == 0 {
+		return
+	}
+	if p.Z.Sign() == 0 {
+		p.X.Set(&q.X)
+		p.Y.Set(&q.Y)
+		p.Z.SetInt64(1)
+		return
+	}
+	P := curve.Params().P
+	mod := func(x *big.Int) *big.Int { return x.Mod(x, P) }
+
+	z1z1 := mod(new(big.Int).Mul(&p.Z, &p.Z))
+	u2 := mod(new(big.Int).Mul(&q.X, z1z1))
+	s2 := mod(new(big.Int).Mul(&q.Y, mod(new(big.Int).Mul(&p.Z, z1z1))))
+	h := mod(new(big.Int).Sub(u2, &p.X))
+	r := mod(new(big.Int).Sub(s2, &p.Y))
+	if h.Sign() == 0 {
+		if r.Sign() == 0 {
+			affine := p.affine(curve)
+			p.Z.SetInt64(0)
+			p.add(common.BigIntToPoint(curve.Double(&affine.X, &affine.Y)), curve)
+			return
+		}
+		p.Z.SetInt64(0)
+		return
+	}
+	hh := mod(new(big.Int).Mul(h, h))
+	hhh := mod(new(big.Int).Mul(h, hh))
+	v := mod(new(big.Int).Mul(&p.X, hh))
+
+	// X3 = r^2 - HHH - 2V, Y3 = r(V - X3) - Y1*HHH, Z3 = Z1*H
+	x3 := new(big.Int).Mul(r, r)
+	x3.Sub(x3, hhh)
+	x3.Sub(x3, new(big.Int).Lsh(v, 1))
+	mod(x3)
+	y3 := new(big.Int).Mul(r, mod(new(big.Int).Sub(v, x3)))
+	y3.Sub(y3, new(big.Int).Mul(&p.Y, hhh))
+	p.X.Set(x3)
+	p.Y.Set(mod(y3))
+	mod(p.Z.Mul(&p.Z, h))
+}
+
+func (p *jacobian) affine(curve elliptic.Curve) common.Point {
+	if p.Z.Sign() == 0 {
+		return common.PointZero()
+	}
+	P := curve.Params().P
+	zInv := new(big.Int).ModInverse(&p.Z, P)
+	zInv2 := new(big.Int).Mul(zInv, zInv)
+	x := new(big.Int).Mul(&p.X, zInv2)
+	y := new(big.Int).Mul(&p.Y, zInv2.Mul(zInv2, zInv))
+	return common.BigIntToPoint(x.Mod(x, P), y.Mod(y, P))
+}
+
+// Accumulator sums ballots as they arrive. The running C1 and C2 are kept in
+// Jacobian coordinates, so adding a ballot costs a few multiplications and no
+// inversion, and the tally only decrypts the sums, whatever the number of
+// ballots.
+type Accumulator struct {
+	Election      common.Hash
+	Options       int
+	EncryptionKey common.Point
+
+	curve  elliptic.Curve
+	tables *Tables
+	count  int
+	c1, c2 jacobian
+}
+
+func NewAccumulator(options int, election common.Hash, encryptionKey common.Point, curve elliptic.Curve) *Accumulator {
+	return &Accumulator{Election: election, Options: options, EncryptionKey: encryptionKey, curve: curve}
+}
+
+// Add adds a ballot without checking it.
+func (a *Accumulator) Add(ballot common.EncryptedBallot) {
+	a.c1.add(ballot.C1, a.curve)
+	a.c2.add(ballot.C2, a.curve)
+	a.count++
+}
+
+// AddVerified adds the ballot only if its validity proof verifies. The
+// tables of the encryption key are built on the first call.
+func (a *Accumulator) AddVerified(ballot common.EncryptedBallot, proof BallotProof) error {
+	if a.tables == nil {
+		a.tables = NewTables(a.EncryptionKey, a.curve)
+	}
+	if !a.tables.VerifyBallot(ballot, proof, a.Options, a.Election) {
+		return fmt.Errorf("%w: ballot %d: validity proof does not verify", ErrInvalidBallot, a.count)
+	}
+	a.Add(ballot)
+	return nil
+}
+
+// AddAllVerified verifies the proofs in parallel and adds, in order, the
+// ballots whose proof verifies. The error lists the positions of the others
+// in ballots.
+func (a *Accumulator) AddAllVerified(ballots []common.EncryptedBallot, proofs []BallotProof) error {
+	if a.tables == nil {
+		a.tables = NewTables(a.EncryptionKey, a.curve)
+	}
+	valid := utils.ParallelMap(lo.Range(len(ballots)), func(i int) bool {
+		return i < len(proofs) && a.tables.VerifyBallot(ballots[i], proofs[i], a.Options, a.Election)
+	})
+	var invalid []int
+	for i, ballot := range ballots {
+		if !valid[i] {
+			invalid = append(invalid, i)
+			continue
+		}
+		a.Add(ballot)
+	}
+	if invalid != nil {
+		return fmt.Errorf("%w: ballots %v: validity proof does not verify", ErrInvalidBallot, invalid)
+	}
+	return nil
+}
+
+// Count is the number of ballots added.
+func (a *Accumulator) Count() int {
+	return a.count
+}
+
+// Sum is the sum (C1, C2) of the ballots added.
+func (a *Accumulator) Sum() common.EncryptedBallot {
+	return common.EncryptedBallot{C1: a.c1.affine(a.curve), C2: a.c2.affine(a.curve)}
+}
+
+// Tally decodes the sum given Z, the combined partial decryptions of Sum().C1.
+func (a *Accumulator) Tally(Z common.Point) []int {
+	return DecryptResults(Z, a.c2.affine(a.curve), a.count, a.Options, a.curve)
+}
+
+type checkpoint struct {
+	Election      common.Hash  `json:"election"`
+	Options       int          `json:"options"`
+	EncryptionKey common.Point `json:"encryptionKey"`
+	Count         int          `json:"count"`
+	C1            common.Point `json:"c1"`
+	C2            common.Point `json:"c2"`
+}
+
+// Checkpoint writes the accumulator state, so counting can resume with
+// RestoreAccumulator after a restart.
+func (a *Accumulator) Checkpoint(w io.Writer) error {
+	sum := a.Sum()
+	return json.NewEncoder(w).Encode(&checkpoint{
+		Election:      a.Election,
+		Options:       a.Options,
+		EncryptionKey: a.EncryptionKey,
+		Count:         a.count,
+		C1:            sum.C1,
+		C2:            sum.C2,
+	})
+}
+
+func RestoreAccumulator(r io.Reader, curve elliptic.Curve) (*Accumulator, error) {
+	var c checkpoint
+	if err := json.NewDecoder(r).Decode(&c); err != nil {
+		return nil, fmt.Errorf("checkpoint: %w", err)
+	}
+	for _, p := range []common.Point{c.EncryptionKey, c.C1, c.C2} {
+		if !p.IsOnCurve(curve) && !(p.X.Sign() == 0 && p.Y.Sign() == 0) {
+			return nil, fmt.Errorf("checkpoint: %w", common.ErrInvalidPoint)
+		}
+	}
+	a := NewAccumulator(c.Options, c.Election, c.EncryptionKey, curve)
+	a.c1.add(c.C1, curve)
+	a.c2.add(c.C2, curve)
+	a.count = c.Count
+	return a, nil
+}
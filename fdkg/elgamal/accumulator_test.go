@@ -0,0 +1,121 @@
+package elgamal
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/delendum-xyz/private-voting/fdkg/common"
+	"github.com/delendum-xyz/private-voting/fdkg/utils"
+)
+
+func TestAccumulatorMatchesAffineSum(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	privKey := utils.RandomBigInt(curve, r)
+	pubKey := common.BigIntToPoint(curve.ScalarBaseMult(privKey.Bytes()))
+
+	var ballots []common.EncryptedBallot
+	for i := 0; i < 20; i++ {
+		ballots = append(ballots, EncryptBallot(i%3, 3, pubKey, curve, r))
+	}
+	// doubling and cancelling out go through the special cases of the addition
+	ballots = append(ballots, ballots[0], ballots[0])
+	ballots = append(ballots, common.EncryptedBallot{C1: negate(ballots[1].C1, curve), C2: negate(ballots[1].C2, curve)})
+
+	accumulator := NewAccumulator(3, election, pubKey, curve)
+	for i, ballot := range ballots {
+		accumulator.Add(ballot)
+		expected := sumBallots(ballots[:i+1], curve)
+		if sum := accumulator.Sum(); !sum.C1.Equal(expected.C1) || !sum.C2.Equal(expected.C2) {
+			t.Fatalf("Sum after %d ballots differs from the affine sum", i+1)
+		}
+	}
+	if accumulator.Count() != len(ballots) {
+		t.Errorf("Expected %d ballots, got %d", len(ballots), accumulator.Count())
+	}
+
+	cancelled := NewAccumulator(3, election, pubKey, curve)
+	cancelled.Add(ballots[1])
+	cancelled.Add(ballots[len(ballots)-1])
+	if sum := cancelled.Sum(); !sum.C1.Equal(common.PointZero()) || !sum.C2.Equal(common.PointZero()) {
+		t.Errorf("Ballot and its negation do not sum to zero")
+	}
+}
+
+func TestAccumulatorCheckpoint(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	privKey := utils.RandomBigInt(curve, r)
+	pubKey := common.BigIntToPoint(curve.ScalarBaseMult(privKey.Bytes()))
+
+	accumulator := NewAccumulator(2, election, pubKey, curve)
+	votes := []int{1, 0, 1, 1, 0, 1}
+	var checkpoint bytes.Buffer
+	for i, vote := range votes {
+		ballot, proof := EncryptBallotWithProof(vote, 2, election, pubKey, curve, r)
+		if err := accumulator.AddVerified(ballot, proof); err != nil {
+			t.Fatal(err)
+		}
+		if i == 2 {
+			if err := accumulator.Checkpoint(&checkpoint); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	ballot, proof := EncryptBallotWithProof(1, 2, common.Hash{2}, pubKey, curve, r)
+	if err := accumulator.AddVerified(ballot, proof); !errors.Is(err, ErrInvalidBallot) {
+		t.Errorf("Expected ErrInvalidBallot for a ballot of another election, got %v", err)
+	}
+
+	restored, err := RestoreAccumulator(&checkpoint, curve)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.Count() != 3 || restored.Election != election || !restored.EncryptionKey.Equal(pubKey) {
+		t.Fatalf("Restored accumulator of %d ballots for %v", restored.Count(), restored.Election)
+	}
+	r = rand.New(rand.NewSource(0))
+	for _, vote := range votes[3:] {
+		restored.Add(EncryptBallot(vote, 2, pubKey, curve, r))
+	}
+	sum := restored.Sum()
+	Z := common.BigIntToPoint(curve.ScalarMult(&sum.C1.X, &sum.C1.Y, privKey.Bytes()))
+	if result := restored.Tally(Z); !reflect.DeepEqual(result, []int{4}) {
+		t.Errorf("Expected 4 votes, got %v", result)
+	}
+}
+
+func TestAccumulatorAddAllVerified(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	privKey := utils.RandomBigInt(curve, r)
+	pubKey := common.BigIntToPoint(curve.ScalarBaseMult(privKey.Bytes()))
+
+	var ballots []common.EncryptedBallot
+	var proofs []BallotProof
+	for i := 0; i < 12; i++ {
+		ballot, proof := EncryptBallotWithProof(i%3, 3, election, pubKey, curve, r)
+		ballots = append(ballots, ballot)
+		proofs = append(proofs, proof)
+	}
+	// a ballot of another election and one proved for a different ballot
+	ballots[4], proofs[4] = EncryptBallotWithProof(1, 3, common.Hash{2}, pubKey, curve, r)
+	proofs[7] = proofs[8]
+
+	sequential := NewAccumulator(3, election, pubKey, curve)
+	for i := range ballots {
+		sequential.AddVerified(ballots[i], proofs[i])
+	}
+	accumulator := NewAccumulator(3, election, pubKey, curve)
+	err := accumulator.AddAllVerified(ballots, proofs)
+	if !errors.Is(err, ErrInvalidBallot) {
+		t.Fatalf("Expected ErrInvalidBallot, got %v", err)
+	}
+	if err.Error() != "invalid ballot: ballots [4 7]: validity proof does not verify" {
+		t.Errorf("Unexpected error %v", err)
+	}
+	sum, expected := accumulator.Sum(), sequential.Sum()
+	if accumulator.Count() != 10 || !sum.C1.Equal(expected.C1) || !sum.C2.Equal(expected.C2) {
+		t.Errorf("AddAllVerified differs from AddVerified one by one")
+	}
+}
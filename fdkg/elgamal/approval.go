@@ -0,0 +1,87 @@
+package elgamal
+
+import (
+	"crypto/elliptic"
+	"fmt"
+	"math/big"
+	"math/rand"
+
+	"github.com/delendum-xyz/private-voting/fdkg/common"
+	"github.com/samber/lo"
+)
+
+// ApprovalBallot encrypts one 0/1 approval per option, each as 0 or H0, with
+// a proof for every entry and a proof that the number of approvals, the sum
+// of the entries, lies within [min, max].
+type ApprovalBallot struct {
+	Entries     []common.EncryptedBallot
+	EntryProofs []BallotProof
+	TotalProof  BallotProof
+}
+
+// multiplesOfH0 are i*H0 for i in [min, max].
+func multiplesOfH0(min, max int, curve elliptic.Curve) []common.Point {
+	return lo.Map(lo.RangeFrom(min, max-min+1), func(i int, _ int) common.Point {
+		return optionMultiple(0, i, curve)
+	})
+}
+
+// EncryptApproval encrypts the approved options out of options, requiring
+// between min and max of them.
+func EncryptApproval(approved []int, options, min, max int, election common.Hash, encryptionKey common.Point, curve elliptic.Curve, r *rand.Rand) ApprovalBallot {
+	if min < 0 || max > options || min > max {
+		panic(fmt.Sprintf("Invalid bounds: [%v, %v] for %v options", min, max, options))
+	}
+	if len(lo.Uniq(approved)) != len(approved) || len(approved) < min || len(approved) > max ||
+		lo.SomeBy(approved, func(option int) bool { return option < 0 || option >= options }) {
+		panic(fmt.Sprintf("Invalid approval: %v, must approve between %v and %v of the options 0..%v", approved, min, max, options-1))
+	}
+	messages := ValidMessages(2)
+	ballot := ApprovalBallot{Entries: make([]common.EncryptedBallot, options), EntryProofs: make([]BallotProof, options)}
+	randomness := make([]big.Int, options)
+	for j := range ballot.Entries {
+		bit := 0
+		if lo.Contains(approved, j) {
+			bit = 1
+		}
+		ballot.Entries[j], randomness[j] = encrypt(messages[bit], encryptionKey, curve, r)
+		ballot.EntryProofs[j] = proveOneOf(ballot.Entries[j], randomness[j], messages, bit, election, encryptionKey, curve, r)
+	}
+	total := sumBallots(ballot.Entries, curve)
+	ballot.TotalProof = proveOneOf(total, sumScalars(randomness, curve), multiplesOfH0(min, max, curve), len(approved)-min, election, encryptionKey, curve, r)
+	return ballot
+}
+
+// Verify checks every entry encrypts 0 or 1 and their sum lies in [min, max].
+func (b ApprovalBallot) Verify(options, min, max int, election common.Hash, encryptionKey common.Point, curve elliptic.Curve) bool {
+	if len(b.Entries) != options || len(b.EntryProofs) != options || min < 0 || min > max {
+		return false
+	}
+	messages := ValidMessages(2)
+	for j := range b.Entries {
+		if !b.EntryProofs[j].verifyOneOf(b.Entries[j], messages, election, encryptionKey, curve) {
+			return false
+		}
+	}
+	return b.TotalProof.verifyOneOf(sumBallots(b.Entries, curve), multiplesOfH0(min, max, curve), election, encryptionKey, curve)
+}
+
+// AggregateApprovals sums the ballots per option. Each sum is an ordinary
+// (C1, C2) pair to be partially decrypted by the talliers.
+func AggregateApprovals(ballots []ApprovalBallot, curve elliptic.Curve) []common.EncryptedBallot {
+	if len(ballots) == 0 {
+		return nil
+	}
+	entries := lo.Map(ballots, func(b ApprovalBallot, _ int) []common.EncryptedBallot { return b.Entries })
+	return lo.Map(entries[0], func(_ common.EncryptedBallot, j int) common.EncryptedBallot {
+		return sumBallots(column(entries, j), curve)
+	})
+}
+
+// DecryptApprovals decodes the approval count of every option given the
+// combined partial decryptions Z[j] of every aggregate[j].C1.
+func DecryptApprovals(Z []common.Point, aggregate []common.EncryptedBallot, votesCount int, curve elliptic.Curve) []int {
+	return lo.Map(aggregate, func(sum common.EncryptedBallot, j int) int {
+		return DecryptResults(Z[j], sum.C2, votesCount, 2, curve)[0]
+	})
+}
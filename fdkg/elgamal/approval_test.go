@@ -0,0 +1,66 @@
+package elgamal
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/delendum-xyz/private-voting/fdkg/common"
+	"github.com/delendum-xyz/private-voting/fdkg/utils"
+	"github.com/samber/lo"
+)
+
+func TestApprovalTally(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	privKey := utils.RandomBigInt(curve, r)
+	pubKey := common.BigIntToPoint(curve.ScalarBaseMult(privKey.Bytes()))
+
+	approvals := [][]int{{0, 2}, {1}, {0, 1, 3}, {2, 0}}
+	ballots := lo.Map(approvals, func(approved []int, _ int) ApprovalBallot {
+		return EncryptApproval(approved, 4, 1, 3, election, pubKey, curve, r)
+	})
+	for i, ballot := range ballots {
+		if !ballot.Verify(4, 1, 3, election, pubKey, curve) {
+			t.Errorf("Valid approval ballot %d rejected", i)
+		}
+	}
+	aggregate := AggregateApprovals(ballots, curve)
+	counts := DecryptApprovals(decryptWith(privKey, aggregate), aggregate, len(ballots), curve)
+	if expected := []int{3, 2, 2, 1}; !reflect.DeepEqual(counts, expected) {
+		t.Errorf("Expected approvals %v, got %v", expected, counts)
+	}
+}
+
+func TestApprovalBallotRejectsOutOfBounds(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	privKey := utils.RandomBigInt(curve, r)
+	pubKey := common.BigIntToPoint(curve.ScalarBaseMult(privKey.Bytes()))
+
+	all := EncryptApproval([]int{0, 1, 2}, 3, 0, 3, election, pubKey, curve, r)
+	if !all.Verify(3, 0, 3, election, pubKey, curve) {
+		t.Fatalf("Valid approval ballot rejected")
+	}
+	if all.Verify(3, 0, 2, election, pubKey, curve) {
+		t.Errorf("Ballot approving 3 options accepted with a maximum of 2")
+	}
+	otherPrivKey := utils.RandomBigInt(curve, r)
+	if all.Verify(3, 0, 3, election, common.BigIntToPoint(curve.ScalarBaseMult(otherPrivKey.Bytes())), curve) {
+		t.Errorf("Ballot accepted under another encryption key")
+	}
+
+	// an entry encrypting 2 fails its 0/1 proof even if the total is in range
+	none := EncryptApproval(nil, 3, 0, 3, election, pubKey, curve, r)
+	stuffed := none
+	stuffed.Entries = append([]common.EncryptedBallot{}, none.Entries...)
+	stuffed.Entries[0] = addBallots(none.Entries[0], scaleBallot(EncryptApproval([]int{0}, 3, 0, 3, election, pubKey, curve, r).Entries[0], 2, curve), curve)
+	if stuffed.Verify(3, 0, 3, election, pubKey, curve) {
+		t.Errorf("Ballot with an entry encrypting 2 accepted")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected a panic for too many approvals")
+		}
+	}()
+	EncryptApproval([]int{0, 1}, 3, 0, 1, election, pubKey, curve, r)
+}
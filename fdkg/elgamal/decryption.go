@@ -3,15 +3,17 @@ package elgamal
 import (
 	"crypto/elliptic"
 	"fmt"
+	"math"
 	"math/big"
-
-	"github.com/torusresearch/pvss/secp256k1"
+	"sync/atomic"
 
 	"github.com/delendum-xyz/private-voting/fdkg/common"
+	"github.com/delendum-xyz/private-voting/fdkg/utils"
+	"github.com/samber/lo"
 )
 
 func computeMFromBallot(b common.EncryptedBallot, votingPrivateKey big.Int, curve elliptic.Curve) common.Point {
-	Z := common.BigIntToPoint(secp256k1.Curve.ScalarMult(&b.C1.X, &b.C1.Y, votingPrivateKey.Bytes()))
+	Z := common.BigIntToPoint(curve.ScalarMult(&b.C1.X, &b.C1.Y, votingPrivateKey.Bytes()))
 	// -Z
 	negZ_Y := new(big.Int).Neg(&Z.Y)
 	negZ_Y.Mod(negZ_Y, curve.Params().P)
@@ -52,38 +54,66 @@ func DecryptResults(Z common.Point, C2 common.Point, votesCount int, options int
 
 func decryptSingleCandidateResults(M common.Point, votesCount int, curve elliptic.Curve) int {
 	for i := 0; i <= votesCount; i++ {
-		X, Y := secp256k1.Curve.ScalarMult(&H0.X, &H0.Y, big.NewInt(int64(i)).Bytes())
-		if X.Cmp(&M.X) == 0 && Y.Cmp(&M.Y) == 0 {
+		if optionMultiple(0, i, curve).Equal(M) {
 			return i
 		}
 	}
 	panic("x not found")
 }
 
+// exhoustiveSearch finds x0*H0 + x1*H1 + x2*H2 + x3*H3 = M with at most
+// max_votes votes in total. The multiples of H0..H3 are computed once and
+// shared by the searches, which try the values of x0 in parallel; once a
+// solution is found, the searches for larger x0 stop.
 func exhoustiveSearch(M common.Point, max_votes int, curve elliptic.Curve) (int, int, int, int) {
-	rounds := 0
-	for i := 0; i <= max_votes; i++ {
-		// x0 * G0
-		iG0_X, iG0_Y := secp256k1.Curve.ScalarMult(&H0.X, &H0.Y, big.NewInt(int64(i)).Bytes())
-		for j := 0; j <= max_votes-i; j++ {
-			// x1 * G1
-			jG1_X, jG1_Y := secp256k1.Curve.ScalarMult(&H1.X, &H1.Y, big.NewInt(int64(j)).Bytes())
-			two_X, two_Y := curve.Add(iG0_X, iG0_Y, jG1_X, jG1_Y)
-			for k := 0; k <= max_votes-i-j; k++ {
-				// x2 * G2
-				kG1_X, kG1_Y := secp256k1.Curve.ScalarMult(&H2.X, &H2.Y, big.NewInt(int64(k)).Bytes())
-				three_X, three_Y := curve.Add(two_X, two_Y, kG1_X, kG1_Y)
-				for l := 0; l <= max_votes-i-j-k; l++ {
-					// x3 * G3
-					lG1_X, lG1_Y := secp256k1.Curve.ScalarMult(&H3.X, &H3.Y, big.NewInt(int64(l)).Bytes())
-					four_X, four_Y := curve.Add(three_X, three_Y, lG1_X, lG1_Y)
-					rounds += 1
-					if four_X.Cmp(&M.X) == 0 && four_Y.Cmp(&M.Y) == 0 {
-						return i, j, k, l
+	// multiples[option][x] = x*H_option
+	multiples := lo.Map(lo.Range(4), func(option int, _ int) []common.Point {
+		return lo.Map(lo.Range(max_votes+1), func(x int, _ int) common.Point {
+			return optionMultiple(option, x, curve)
+		})
+	})
+	found := int64(math.MaxInt64)
+	solutions := utils.ParallelMap(lo.Range(max_votes+1), func(i int) []int {
+		return searchWithX0(M, i, max_votes, &found, multiples, curve)
+	})
+	for _, solution := range solutions {
+		if solution != nil {
+			return solution[0], solution[1], solution[2], solution[3]
+		}
+	}
+	panic(fmt.Sprintf("Could not find the solution for up to %v votes", max_votes))
+}
+
+// searchWithX0 searches the solutions with x0 = i, unless one with a smaller
+// x0 has been found.
+func searchWithX0(M common.Point, i int, max_votes int, found *int64, multiples [][]common.Point, curve elliptic.Curve) []int {
+	// x0 * G0
+	iG0 := multiples[0][i]
+	for j := 0; j <= max_votes-i; j++ {
+		if atomic.LoadInt64(found) < int64(i) {
+			return nil
+		}
+		// x1 * G1
+		jG1 := multiples[1][j]
+		two_X, two_Y := curve.Add(&iG0.X, &iG0.Y, &jG1.X, &jG1.Y)
+		for k := 0; k <= max_votes-i-j; k++ {
+			// x2 * G2
+			kG2 := multiples[2][k]
+			three_X, three_Y := curve.Add(two_X, two_Y, &kG2.X, &kG2.Y)
+			for l := 0; l <= max_votes-i-j-k; l++ {
+				// x3 * G3
+				lG3 := multiples[3][l]
+				four_X, four_Y := curve.Add(three_X, three_Y, &lG3.X, &lG3.Y)
+				if four_X.Cmp(&M.X) == 0 && four_Y.Cmp(&M.Y) == 0 {
+					for current := atomic.LoadInt64(found); int64(i) < current; current = atomic.LoadInt64(found) {
+						if atomic.CompareAndSwapInt64(found, current, int64(i)) {
+							break
+						}
 					}
+					return []int{i, j, k, l}
 				}
 			}
 		}
 	}
-	panic(fmt.Sprintf("Could not find the solution after %v rounds", rounds))
+	return nil
 }
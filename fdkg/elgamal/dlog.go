@@ -0,0 +1,59 @@
+package elgamal
+
+import (
+	"crypto/elliptic"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/delendum-xyz/private-voting/fdkg/common"
+)
+
+var ErrNoDiscreteLog = errors.New("no discrete log in range")
+
+// dlogTable solves x*base = M for x in [0, max] by baby-step giant-step: it
+// stores j*base for the m = ceil(sqrt(max+1)) baby steps and then walks M
+// down by m*base at most m times, so both time and memory are O(sqrt(max)).
+type dlogTable struct {
+	curve elliptic.Curve
+	m     int
+	baby  map[string]int
+	giant common.Point
+}
+
+func pointKey(p common.Point) string {
+	return p.X.Text(16) + "," + p.Y.Text(16)
+}
+
+func newDlogTable(base common.Point, max int, curve elliptic.Curve) *dlogTable {
+	m := int(math.Ceil(math.Sqrt(float64(max + 1))))
+	t := &dlogTable{curve: curve, m: m, baby: make(map[string]int, m)}
+	step := common.PointZero()
+	for j := 0; j < m; j++ {
+		t.baby[pointKey(step)] = j
+		step = common.BigIntToPoint(curve.Add(&step.X, &step.Y, &base.X, &base.Y))
+	}
+	mBase := common.BigIntToPoint(curve.ScalarMult(&base.X, &base.Y, big.NewInt(int64(m)).Bytes()))
+	t.giant = negate(mBase, curve)
+	return t
+}
+
+func (t *dlogTable) solve(M common.Point, max int) (int, bool) {
+	gamma := M
+	for i := 0; i*t.m <= max; i++ {
+		if j, found := t.baby[pointKey(gamma)]; found && i*t.m+j <= max {
+			return i*t.m + j, true
+		}
+		gamma = common.BigIntToPoint(t.curve.Add(&gamma.X, &gamma.Y, &t.giant.X, &t.giant.Y))
+	}
+	return 0, false
+}
+
+// SolveDiscreteLog finds x in [0, max] with x*base = M.
+func SolveDiscreteLog(M, base common.Point, max int, curve elliptic.Curve) (int, error) {
+	if x, found := newDlogTable(base, max, curve).solve(M, max); found {
+		return x, nil
+	}
+	return 0, fmt.Errorf("%w: [0, %d]", ErrNoDiscreteLog, max)
+}
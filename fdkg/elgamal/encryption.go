@@ -26,13 +26,13 @@ func EncryptEnum(x int, votingPublicKey common.Point, curve elliptic.Curve, r *r
 		{X: H3.X, Y: H3.Y},
 	}[x]
 	blindingFactor := utils.RandomBigInt(curve, r)
-	comm := common.BigIntToPoint(secp256k1.Curve.ScalarBaseMult(blindingFactor.Bytes()))
+	comm := common.BigIntToPoint(curve.ScalarBaseMult(blindingFactor.Bytes()))
 
 	// k_i * E
-	X, Y := secp256k1.Curve.ScalarMult(&votingPublicKey.X, &votingPublicKey.Y, blindingFactor.Bytes())
+	X, Y := curve.ScalarMult(&votingPublicKey.X, &votingPublicKey.Y, blindingFactor.Bytes())
 
 	// (k_i * G, k_i * E + m * H)
-	return common.EncryptedBallot{C1: comm, C2: common.BigIntToPoint(secp256k1.Curve.Add(X, Y, &generator.X, &generator.Y))}
+	return common.EncryptedBallot{C1: comm, C2: common.BigIntToPoint(curve.Add(X, Y, &generator.X, &generator.Y))}
 }
 
 func EncryptXonY(x int, y int, votingPublicKey common.Point, curve elliptic.Curve, r *rand.Rand) common.EncryptedBallot {
@@ -44,14 +44,14 @@ func EncryptXonY(x int, y int, votingPublicKey common.Point, curve elliptic.Curv
 		{X: H3.X, Y: H3.Y},
 	}[y]
 	blindingFactor := utils.RandomBigInt(curve, r)
-	comm := common.BigIntToPoint(secp256k1.Curve.ScalarBaseMult(blindingFactor.Bytes()))
+	comm := common.BigIntToPoint(curve.ScalarBaseMult(blindingFactor.Bytes()))
 
 	// k_i * E
-	X, Y := secp256k1.Curve.ScalarMult(&votingPublicKey.X, &votingPublicKey.Y, blindingFactor.Bytes())
+	X, Y := curve.ScalarMult(&votingPublicKey.X, &votingPublicKey.Y, blindingFactor.Bytes())
 
-	xH_X, xH_Y := secp256k1.Curve.ScalarMult(&generator.X, &generator.Y, big.NewInt(int64(x)).Bytes())
+	xH_X, xH_Y := curve.ScalarMult(&generator.X, &generator.Y, big.NewInt(int64(x)).Bytes())
 	// (k_i * G, k_i * E + m * H)
-	return common.EncryptedBallot{C1: comm, C2: common.BigIntToPoint(secp256k1.Curve.Add(X, Y, xH_X, xH_Y))}
+	return common.EncryptedBallot{C1: comm, C2: common.BigIntToPoint(curve.Add(X, Y, xH_X, xH_Y))}
 }
 func EncryptBallot(vote int, options int, encryptionKey common.Point, curve elliptic.Curve, r *rand.Rand) common.EncryptedBallot {
 	if vote < 0 || vote > options-1 {
@@ -70,14 +70,14 @@ func EncryptSingleCandidate(vote int, encryptionKey common.Point, curve elliptic
 	x := big.NewInt(int64(vote))
 
 	blindingFactor := utils.RandomBigInt(curve, r)
-	comm := common.BigIntToPoint(secp256k1.Curve.ScalarBaseMult(blindingFactor.Bytes()))
+	comm := common.BigIntToPoint(curve.ScalarBaseMult(blindingFactor.Bytes()))
 
 	// k_i * E
-	X, Y := secp256k1.Curve.ScalarMult(&encryptionKey.X, &encryptionKey.Y, blindingFactor.Bytes())
+	X, Y := curve.ScalarMult(&encryptionKey.X, &encryptionKey.Y, blindingFactor.Bytes())
 
 	// (k_i * G, k_i * E + m * H)
-	mHX, mHY := secp256k1.Curve.ScalarMult(&H0.X, &H0.Y, x.Bytes())
-	return common.EncryptedBallot{C1: comm, C2: common.BigIntToPoint(secp256k1.Curve.Add(X, Y, mHX, mHY))}
+	mHX, mHY := curve.ScalarMult(&H0.X, &H0.Y, x.Bytes())
+	return common.EncryptedBallot{C1: comm, C2: common.BigIntToPoint(curve.Add(X, Y, mHX, mHY))}
 }
 
 func EncryptMultiCandidate(vote int, options int, encryptionKey common.Point, curve elliptic.Curve, r *rand.Rand) common.EncryptedBallot {
@@ -88,12 +88,12 @@ func EncryptMultiCandidate(vote int, options int, encryptionKey common.Point, cu
 		{X: H3.X, Y: H3.Y},
 	}[vote]
 	blindingFactor := utils.RandomBigInt(curve, r)
-	comm := common.BigIntToPoint(secp256k1.Curve.ScalarBaseMult(blindingFactor.Bytes()))
+	comm := common.BigIntToPoint(curve.ScalarBaseMult(blindingFactor.Bytes()))
 
 	// k_i * E
-	X, Y := secp256k1.Curve.ScalarMult(&encryptionKey.X, &encryptionKey.Y, blindingFactor.Bytes())
+	X, Y := curve.ScalarMult(&encryptionKey.X, &encryptionKey.Y, blindingFactor.Bytes())
 
 	// (k_i * G, k_i * E + m * H)
-	return common.EncryptedBallot{C1: comm, C2: common.BigIntToPoint(secp256k1.Curve.Add(X, Y, &generator.X, &generator.Y))}
+	return common.EncryptedBallot{C1: comm, C2: common.BigIntToPoint(curve.Add(X, Y, &generator.X, &generator.Y))}
 
 }
@@ -14,6 +14,8 @@ const ITERATIONS = 100
 
 var curve = secp256k1.Curve
 
+var election = common.Hash{1}
+
 func TestBooleanEncryption(t *testing.T) {
 	for i := 0; i < ITERATIONS; i++ {
 		r := rand.New(rand.NewSource(int64(i)))
@@ -0,0 +1,160 @@
+package elgamal
+
+import (
+	"crypto/elliptic"
+	"math/big"
+	"math/rand"
+
+	"github.com/delendum-xyz/private-voting/fdkg/common"
+	"github.com/delendum-xyz/private-voting/fdkg/utils"
+)
+
+// BallotProof is a disjunctive Chaum-Pedersen proof that a ballot encrypts
+// one of the valid messages for its number of options, without revealing
+// which: for every option i there is a challenge c_i and response z_i, and
+// the challenges sum to the hash of the election, statement and commitments,
+// so a proof cannot be replayed in another election.
+type BallotProof struct {
+	Challenges []big.Int
+	Responses  []big.Int
+}
+
+// ValidMessages are the points a ballot may encrypt: 0*H0 or 1*H0 for a
+// yes/no vote, otherwise one of H0..H3.
+func ValidMessages(options int) []common.Point {
+	if options == 2 {
+		return []common.Point{common.PointZero(), {X: H0.X, Y: H0.Y}}
+	}
+	return []common.Point{
+		{X: H0.X, Y: H0.Y},
+		{X: H1.X, Y: H1.Y},
+		{X: H2.X, Y: H2.Y},
+		{X: H3.X, Y: H3.Y},
+	}[:options]
+}
+
+func negate(p common.Point, curve elliptic.Curve) common.Point {
+	if p.X.Sign() == 0 && p.Y.Sign() == 0 {
+		return p
+	}
+	y := new(big.Int).Neg(&p.Y)
+	return common.BigIntToPoint(&p.X, y.Mod(y, curve.Params().P))
+}
+
+// linear computes zP - c*Q for zP = z*P.
+func linear(zP common.Point, c *big.Int, Q common.Point, curve elliptic.Curve) common.Point {
+	cQ := negate(common.BigIntToPoint(curve.ScalarMult(&Q.X, &Q.Y, c.Bytes())), curve)
+	return common.BigIntToPoint(curve.Add(&zP.X, &zP.Y, &cQ.X, &cQ.Y))
+}
+
+func ballotChallenge(election common.Hash, encryptionKey common.Point, b common.EncryptedBallot, commitments []common.Point, curve elliptic.Curve) *big.Int {
+	data := [][]byte{[]byte("fdkg/ballot"), election[:], encryptionKey.Marshal(curve), b.C1.Marshal(curve), b.C2.Marshal(curve)}
+	for i := range commitments {
+		data = append(data, commitments[i].Marshal(curve))
+	}
+	c := new(big.Int).SetBytes(utils.Keccak256(data...))
+	return c.Mod(c, curve.Params().N)
+}
+
+// encrypt encrypts the message point m under encryptionKey and returns the
+// ballot together with its randomness k.
+func encrypt(m common.Point, encryptionKey common.Point, curve elliptic.Curve, r *rand.Rand) (common.EncryptedBallot, big.Int) {
+	return encryptWith(keyBases{encryptionKey, curve}, m, curve, r)
+}
+
+func encryptWith(b bases, m common.Point, curve elliptic.Curve, r *rand.Rand) (common.EncryptedBallot, big.Int) {
+	k := utils.RandomBigInt(curve, r)
+	kE := b.mulKey(&k)
+	return common.EncryptedBallot{
+		C1: b.mulG(&k),
+		C2: common.BigIntToPoint(curve.Add(&kE.X, &kE.Y, &m.X, &m.Y)),
+	}, k
+}
+
+// EncryptBallotWithProof encrypts the vote like EncryptBallot and proves the
+// ballot encrypts a valid message.
+func EncryptBallotWithProof(vote int, options int, election common.Hash, encryptionKey common.Point, curve elliptic.Curve, r *rand.Rand) (common.EncryptedBallot, BallotProof) {
+	messages := ValidMessages(options)
+	if vote < 0 || vote >= len(messages) {
+		panic("Invalid vote")
+	}
+	ballot, k := encrypt(messages[vote], encryptionKey, curve, r)
+	return ballot, proveOneOf(ballot, k, messages, vote, election, encryptionKey, curve, r)
+}
+
+// proveOneOf proves that ballot, encrypted with randomness k, encrypts
+// messages[index] without revealing the index.
+func proveOneOf(ballot common.EncryptedBallot, k big.Int, messages []common.Point, index int, election common.Hash, encryptionKey common.Point, curve elliptic.Curve, r *rand.Rand) BallotProof {
+	return proveOneOfWith(keyBases{encryptionKey, curve}, ballot, k, messages, index, election, curve, r)
+}
+
+func proveOneOfWith(b bases, ballot common.EncryptedBallot, k big.Int, messages []common.Point, index int, election common.Hash, curve elliptic.Curve, r *rand.Rand) BallotProof {
+	N := curve.Params().N
+
+	proof := BallotProof{Challenges: make([]big.Int, len(messages)), Responses: make([]big.Int, len(messages))}
+	commitments := make([]common.Point, 2*len(messages))
+	w := utils.RandomBigInt(curve, r)
+	for i, m := range messages {
+		if i == index {
+			commitments[2*i] = b.mulG(&w)
+			commitments[2*i+1] = b.mulKey(&w)
+			continue
+		}
+		// simulate the proof for the other messages
+		proof.Challenges[i] = utils.RandomBigInt(curve, r)
+		proof.Responses[i] = utils.RandomBigInt(curve, r)
+		negM := negate(m, curve)
+		D := common.BigIntToPoint(curve.Add(&ballot.C2.X, &ballot.C2.Y, &negM.X, &negM.Y))
+		commitments[2*i] = linear(b.mulG(&proof.Responses[i]), &proof.Challenges[i], ballot.C1, curve)
+		commitments[2*i+1] = linear(b.mulKey(&proof.Responses[i]), &proof.Challenges[i], D, curve)
+	}
+
+	c := ballotChallenge(election, b.key(), ballot, commitments, curve)
+	for i := range messages {
+		if i != index {
+			c.Sub(c, &proof.Challenges[i])
+		}
+	}
+	proof.Challenges[index] = *c.Mod(c, N)
+	z := new(big.Int).Mul(&proof.Challenges[index], &k)
+	z.Add(z, &w)
+	proof.Responses[index] = *z.Mod(z, N)
+	return proof
+}
+
+// Verify checks the proof for a ballot of the election encrypted under
+// encryptionKey.
+func (p BallotProof) Verify(ballot common.EncryptedBallot, options int, election common.Hash, encryptionKey common.Point, curve elliptic.Curve) bool {
+	return p.verifyOneOf(ballot, ValidMessages(options), election, encryptionKey, curve)
+}
+
+func (p BallotProof) verifyOneOf(ballot common.EncryptedBallot, messages []common.Point, election common.Hash, encryptionKey common.Point, curve elliptic.Curve) bool {
+	return p.verifyOneOfWith(keyBases{encryptionKey, curve}, ballot, messages, election, curve)
+}
+
+func (p BallotProof) verifyOneOfWith(b bases, ballot common.EncryptedBallot, messages []common.Point, election common.Hash, curve elliptic.Curve) bool {
+	if len(p.Challenges) != len(messages) || len(p.Responses) != len(messages) {
+		return false
+	}
+	if !ballot.C1.IsOnCurve(curve) || !ballot.C2.IsOnCurve(curve) {
+		return false
+	}
+	N := curve.Params().N
+	commitments := make([]common.Point, 2*len(messages))
+	sum := new(big.Int)
+	for i, m := range messages {
+		c, z := &p.Challenges[i], &p.Responses[i]
+		if c.Sign() < 0 || c.Cmp(N) >= 0 || z.Sign() < 0 || z.Cmp(N) >= 0 {
+			return false
+		}
+		negM := negate(m, curve)
+		D := common.BigIntToPoint(curve.Add(&ballot.C2.X, &ballot.C2.Y, &negM.X, &negM.Y))
+		commitments[2*i] = linear(b.mulG(z), c, ballot.C1, curve)
+		commitments[2*i+1] = linear(b.mulKey(z), c, D, curve)
+		if !commitments[2*i].IsOnCurve(curve) || !commitments[2*i+1].IsOnCurve(curve) {
+			return false
+		}
+		sum.Add(sum, c)
+	}
+	return sum.Mod(sum, N).Cmp(ballotChallenge(election, b.key(), ballot, commitments, curve)) == 0
+}
@@ -0,0 +1,62 @@
+package elgamal
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/delendum-xyz/private-voting/fdkg/common"
+	"github.com/delendum-xyz/private-voting/fdkg/utils"
+)
+
+func TestBallotProof(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	privKey := utils.RandomBigInt(curve, r)
+	pubKey := common.BigIntToPoint(curve.ScalarBaseMult(privKey.Bytes()))
+
+	for _, options := range []int{2, 3, 4} {
+		for vote := 0; vote < len(ValidMessages(options)); vote++ {
+			ballot, proof := EncryptBallotWithProof(vote, options, election, pubKey, curve, r)
+			if !proof.Verify(ballot, options, election, pubKey, curve) {
+				t.Errorf("options=%d vote=%d: valid proof rejected", options, vote)
+			}
+			if options == 2 && DecryptSingleCandidateBallot(ballot, 1, privKey, curve) != vote {
+				t.Errorf("options=2 vote=%d: ballot does not decrypt to the vote", vote)
+			}
+			if options > 2 {
+				counts := make([]int, 4)
+				counts[0], counts[1], counts[2], counts[3] = DecryptMultiCandidateBallot(ballot, 1, privKey, curve)
+				if counts[vote] != 1 {
+					t.Errorf("options=%d vote=%d: ballot decrypts to %v", options, vote, counts)
+				}
+			}
+		}
+	}
+}
+
+func TestBallotProofRejectsInvalidBallots(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	privKey := utils.RandomBigInt(curve, r)
+	pubKey := common.BigIntToPoint(curve.ScalarBaseMult(privKey.Bytes()))
+
+	// a proof does not carry over to another ballot, e.g. one counting twice
+	ballot, proof := EncryptBallotWithProof(1, 2, election, pubKey, curve, r)
+	stuffed := common.EncryptedBallot{C1: ballot.C1, C2: common.BigIntToPoint(curve.Add(&ballot.C2.X, &ballot.C2.Y, &H0.X, &H0.Y))}
+	if proof.Verify(stuffed, 2, election, pubKey, curve) {
+		t.Errorf("Proof accepted for a ballot encrypting 2")
+	}
+	if proof.Verify(ballot, 3, election, pubKey, curve) {
+		t.Errorf("Proof accepted for a different number of options")
+	}
+	otherPrivKey := utils.RandomBigInt(curve, r)
+	otherKey := common.BigIntToPoint(curve.ScalarBaseMult(otherPrivKey.Bytes()))
+	if proof.Verify(ballot, 2, election, otherKey, curve) {
+		t.Errorf("Proof accepted under another encryption key")
+	}
+	if proof.Verify(ballot, 2, common.Hash{2}, pubKey, curve) {
+		t.Errorf("Proof accepted in another election")
+	}
+	proof.Challenges[0], proof.Challenges[1] = proof.Challenges[1], proof.Challenges[0]
+	if proof.Verify(ballot, 2, election, pubKey, curve) {
+		t.Errorf("Proof with swapped challenges accepted")
+	}
+}
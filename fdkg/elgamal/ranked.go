@@ -0,0 +1,239 @@
+package elgamal
+
+import (
+	"crypto/elliptic"
+	"errors"
+	"fmt"
+	"math/big"
+	"math/rand"
+
+	"github.com/delendum-xyz/private-voting/fdkg/common"
+	"github.com/samber/lo"
+)
+
+var ErrInvalidRanking = errors.New("decrypted ballot is not a ranking")
+
+// RankedBallot encrypts a ranking of n options as an n x n permutation
+// matrix: Entries[j][k] encrypts H0 if option j is ranked at position k
+// (0 being the first choice) and 0 otherwise. Every entry is proven to
+// encrypt 0 or H0, and every row and column to sum to exactly H0, which
+// together prove the matrix is a permutation.
+type RankedBallot struct {
+	Entries      [][]common.EncryptedBallot
+	EntryProofs  [][]BallotProof
+	RowProofs    []BallotProof
+	ColumnProofs []BallotProof
+}
+
+func addBallots(a, b common.EncryptedBallot, curve elliptic.Curve) common.EncryptedBallot {
+	return common.EncryptedBallot{
+		C1: common.BigIntToPoint(curve.Add(&a.C1.X, &a.C1.Y, &b.C1.X, &b.C1.Y)),
+		C2: common.BigIntToPoint(curve.Add(&a.C2.X, &a.C2.Y, &b.C2.X, &b.C2.Y)),
+	}
+}
+
+func scaleBallot(b common.EncryptedBallot, s int, curve elliptic.Curve) common.EncryptedBallot {
+	scalar := big.NewInt(int64(s)).Bytes()
+	return common.EncryptedBallot{
+		C1: common.BigIntToPoint(curve.ScalarMult(&b.C1.X, &b.C1.Y, scalar)),
+		C2: common.BigIntToPoint(curve.ScalarMult(&b.C2.X, &b.C2.Y, scalar)),
+	}
+}
+
+func sumBallots(ballots []common.EncryptedBallot, curve elliptic.Curve) common.EncryptedBallot {
+	return lo.Reduce(ballots, func(sum common.EncryptedBallot, b common.EncryptedBallot, _ int) common.EncryptedBallot {
+		return addBallots(sum, b, curve)
+	}, common.EncryptedBallot{C1: common.PointZero(), C2: common.PointZero()})
+}
+
+func column(entries [][]common.EncryptedBallot, k int) []common.EncryptedBallot {
+	return lo.Map(entries, func(row []common.EncryptedBallot, _ int) common.EncryptedBallot { return row[k] })
+}
+
+func sumScalars(scalars []big.Int, curve elliptic.Curve) big.Int {
+	sum := new(big.Int)
+	for i := range scalars {
+		sum.Add(sum, &scalars[i])
+	}
+	return *sum.Mod(sum, curve.Params().N)
+}
+
+// EncryptRanking encrypts a ranking, ranking[k] being the option at position
+// k, with the proofs that it is a permutation of all the options.
+func EncryptRanking(ranking []int, election common.Hash, encryptionKey common.Point, curve elliptic.Curve, r *rand.Rand) RankedBallot {
+	n := len(ranking)
+	if n < 2 || len(lo.Uniq(ranking)) != n || lo.Min(ranking) != 0 || lo.Max(ranking) != n-1 {
+		panic(fmt.Sprintf("Invalid ranking: %v, must order all options 0..%v", ranking, n-1))
+	}
+	messages := ValidMessages(2)
+	ballot := RankedBallot{
+		Entries:      make([][]common.EncryptedBallot, n),
+		EntryProofs:  make([][]BallotProof, n),
+		RowProofs:    make([]BallotProof, n),
+		ColumnProofs: make([]BallotProof, n),
+	}
+	randomness := make([][]big.Int, n)
+	for j := range ballot.Entries {
+		ballot.Entries[j] = make([]common.EncryptedBallot, n)
+		ballot.EntryProofs[j] = make([]BallotProof, n)
+		randomness[j] = make([]big.Int, n)
+		for k := range ballot.Entries[j] {
+			bit := 0
+			if ranking[k] == j {
+				bit = 1
+			}
+			ballot.Entries[j][k], randomness[j][k] = encrypt(messages[bit], encryptionKey, curve, r)
+			ballot.EntryProofs[j][k] = proveOneOf(ballot.Entries[j][k], randomness[j][k], messages, bit, election, encryptionKey, curve, r)
+		}
+	}
+	one := messages[1:]
+	for i := 0; i < n; i++ {
+		row := sumBallots(ballot.Entries[i], curve)
+		ballot.RowProofs[i] = proveOneOf(row, sumScalars(randomness[i], curve), one, 0, election, encryptionKey, curve, r)
+		columnRandomness := lo.Map(randomness, func(row []big.Int, _ int) big.Int { return row[i] })
+		ballot.ColumnProofs[i] = proveOneOf(sumBallots(column(ballot.Entries, i), curve), sumScalars(columnRandomness, curve), one, 0, election, encryptionKey, curve, r)
+	}
+	return ballot
+}
+
+// Verify checks the ballot is an encrypted permutation matrix over the given
+// number of options.
+func (b RankedBallot) Verify(options int, election common.Hash, encryptionKey common.Point, curve elliptic.Curve) bool {
+	if len(b.Entries) != options || len(b.EntryProofs) != options || len(b.RowProofs) != options || len(b.ColumnProofs) != options {
+		return false
+	}
+	messages := ValidMessages(2)
+	for j := range b.Entries {
+		if len(b.Entries[j]) != options || len(b.EntryProofs[j]) != options {
+			return false
+		}
+		for k := range b.Entries[j] {
+			if !b.EntryProofs[j][k].verifyOneOf(b.Entries[j][k], messages, election, encryptionKey, curve) {
+				return false
+			}
+		}
+	}
+	for i := 0; i < options; i++ {
+		if !b.RowProofs[i].verifyOneOf(sumBallots(b.Entries[i], curve), messages[1:], election, encryptionKey, curve) ||
+			!b.ColumnProofs[i].verifyOneOf(sumBallots(column(b.Entries, i), curve), messages[1:], election, encryptionKey, curve) {
+			return false
+		}
+	}
+	return true
+}
+
+// BordaScores derives, for every option, an encryption of its Borda score
+// n-1-k for position k, so the first choice scores n-1 and the last 0.
+func (b RankedBallot) BordaScores(curve elliptic.Curve) []common.EncryptedBallot {
+	n := len(b.Entries)
+	return lo.Map(b.Entries, func(row []common.EncryptedBallot, _ int) common.EncryptedBallot {
+		return sumBallots(lo.Map(row, func(entry common.EncryptedBallot, k int) common.EncryptedBallot {
+			return scaleBallot(entry, n-1-k, curve)
+		}), curve)
+	})
+}
+
+// Positions derives, for every option, an encryption of its position k in
+// the ranking.
+func (b RankedBallot) Positions(curve elliptic.Curve) []common.EncryptedBallot {
+	return lo.Map(b.Entries, func(row []common.EncryptedBallot, _ int) common.EncryptedBallot {
+		return sumBallots(lo.Map(row, func(entry common.EncryptedBallot, k int) common.EncryptedBallot {
+			return scaleBallot(entry, k, curve)
+		}), curve)
+	})
+}
+
+// AggregateBorda sums the Borda scores of the ballots per option. Each sum is
+// an ordinary (C1, C2) pair to be partially decrypted by the talliers.
+func AggregateBorda(ballots []RankedBallot, curve elliptic.Curve) []common.EncryptedBallot {
+	if len(ballots) == 0 {
+		return nil
+	}
+	scores := lo.Map(ballots, func(b RankedBallot, _ int) []common.EncryptedBallot { return b.BordaScores(curve) })
+	return lo.Map(scores[0], func(_ common.EncryptedBallot, j int) common.EncryptedBallot {
+		return sumBallots(column(scores, j), curve)
+	})
+}
+
+// DecryptBorda decodes the aggregated Borda scores given the combined partial
+// decryptions Z[j] of every aggregate[j].C1.
+func DecryptBorda(Z []common.Point, aggregate []common.EncryptedBallot, votesCount int, curve elliptic.Curve) []int {
+	max := votesCount * (len(aggregate) - 1)
+	return lo.Map(aggregate, func(sum common.EncryptedBallot, j int) int {
+		return DecryptResults(Z[j], sum.C2, max, 2, curve)[0]
+	})
+}
+
+// MaxPackedOptions bounds the options of a packed ranking, whose value is
+// below n^n and is decoded with a discrete-log search of about n^(n/2) steps.
+const MaxPackedOptions = 8
+
+// PackedRanking packs the position vector into a single ciphertext of
+// sum of k_j*n^j*H0 for option j at position k_j, so that a ranking can go
+// through the verifiable shuffle of the mix-net like any other ballot before
+// it is decrypted for an instant-runoff count.
+func (b RankedBallot) PackedRanking(curve elliptic.Curve) common.EncryptedBallot {
+	n := len(b.Entries)
+	if n > MaxPackedOptions {
+		panic(fmt.Sprintf("cannot pack a ranking of %d options", n))
+	}
+	weight := 1
+	return sumBallots(lo.Map(b.Positions(curve), func(position common.EncryptedBallot, _ int) common.EncryptedBallot {
+		scaled := scaleBallot(position, weight, curve)
+		weight *= n
+		return scaled
+	}), curve)
+}
+
+// DecodeRanking decodes the decrypted message M = C2 - Z of a packed ranking
+// of the given number of options and returns the ranking.
+func DecodeRanking(M common.Point, options int, curve elliptic.Curve) ([]int, error) {
+	if options < 1 || options > MaxPackedOptions {
+		return nil, fmt.Errorf("%w: cannot unpack %d options", ErrInvalidRanking, options)
+	}
+	max := 1
+	for i := 0; i < options; i++ {
+		max *= options
+	}
+	x, err := SolveDiscreteLog(M, common.Point{X: H0.X, Y: H0.Y}, max-1, curve)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRanking, err)
+	}
+	ranking := make([]int, options)
+	seen := make([]bool, options)
+	for j := 0; j < options; j++ {
+		k := x % options
+		x /= options
+		if seen[k] {
+			return nil, fmt.Errorf("%w: two options at position %d", ErrInvalidRanking, k)
+		}
+		seen[k] = true
+		ranking[k] = j
+	}
+	return ranking, nil
+}
+
+// InstantRunoff counts decrypted rankings: each round every ranking counts
+// for its highest remaining option, and the option with the fewest votes is
+// eliminated (the lowest index on a tie) until one has a majority. It returns
+// the winner and the vote counts of every round.
+func InstantRunoff(rankings [][]int, options int) (int, [][]int) {
+	eliminated := make([]bool, options)
+	var rounds [][]int
+	for {
+		counts := make([]int, options)
+		for _, ranking := range rankings {
+			if choice, found := lo.Find(ranking, func(option int) bool { return !eliminated[option] }); found {
+				counts[choice]++
+			}
+		}
+		rounds = append(rounds, counts)
+		remaining := lo.Filter(lo.Range(options), func(option int, _ int) bool { return !eliminated[option] })
+		leader := lo.MaxBy(remaining, func(a, b int) bool { return counts[a] > counts[b] })
+		if len(remaining) == 1 || 2*counts[leader] > lo.Sum(counts) {
+			return leader, rounds
+		}
+		loser := lo.MinBy(remaining, func(a, b int) bool { return counts[a] < counts[b] })
+		eliminated[loser] = true
+	}
+}
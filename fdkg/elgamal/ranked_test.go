@@ -0,0 +1,105 @@
+package elgamal
+
+import (
+	"errors"
+	"math/big"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/delendum-xyz/private-voting/fdkg/common"
+	"github.com/delendum-xyz/private-voting/fdkg/utils"
+	"github.com/samber/lo"
+)
+
+func decryptWith(privKey big.Int, ballots []common.EncryptedBallot) []common.Point {
+	return lo.Map(ballots, func(b common.EncryptedBallot, _ int) common.Point {
+		return common.BigIntToPoint(curve.ScalarMult(&b.C1.X, &b.C1.Y, privKey.Bytes()))
+	})
+}
+
+func TestBordaTally(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	privKey := utils.RandomBigInt(curve, r)
+	pubKey := common.BigIntToPoint(curve.ScalarBaseMult(privKey.Bytes()))
+
+	rankings := [][]int{{0, 1, 2}, {2, 0, 1}, {0, 2, 1}, {1, 0, 2}}
+	ballots := lo.Map(rankings, func(ranking []int, _ int) RankedBallot { return EncryptRanking(ranking, election, pubKey, curve, r) })
+	for i, ballot := range ballots {
+		if !ballot.Verify(3, election, pubKey, curve) {
+			t.Errorf("Valid ranked ballot %d rejected", i)
+		}
+	}
+	aggregate := AggregateBorda(ballots, curve)
+	scores := DecryptBorda(decryptWith(privKey, aggregate), aggregate, len(ballots), curve)
+	if expected := []int{6, 3, 3}; !reflect.DeepEqual(scores, expected) {
+		t.Errorf("Expected Borda scores %v, got %v", expected, scores)
+	}
+}
+
+func TestRankedBallotRejectsNonPermutations(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	privKey := utils.RandomBigInt(curve, r)
+	pubKey := common.BigIntToPoint(curve.ScalarBaseMult(privKey.Bytes()))
+
+	ballot := EncryptRanking([]int{1, 0, 2}, election, pubKey, curve, r)
+	other := EncryptRanking([]int{0, 1, 2}, election, pubKey, curve, r)
+	// ranking option 0 first twice: rows no longer sum to one
+	stuffed := ballot
+	stuffed.Entries = lo.Map(ballot.Entries, func(row []common.EncryptedBallot, _ int) []common.EncryptedBallot {
+		return append([]common.EncryptedBallot{}, row...)
+	})
+	stuffed.Entries[0][0] = other.Entries[0][0]
+	stuffed.EntryProofs = lo.Map(ballot.EntryProofs, func(row []BallotProof, _ int) []BallotProof { return append([]BallotProof{}, row...) })
+	stuffed.EntryProofs[0][0] = other.EntryProofs[0][0]
+	if stuffed.Verify(3, election, pubKey, curve) {
+		t.Errorf("Ballot ranking an option twice accepted")
+	}
+	if ballot.Verify(4, election, pubKey, curve) {
+		t.Errorf("Ballot accepted for another number of options")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected a panic for an incomplete ranking")
+		}
+	}()
+	EncryptRanking([]int{0, 0, 1}, election, pubKey, curve, r)
+}
+
+func TestInstantRunoffOfPackedRankings(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	privKey := utils.RandomBigInt(curve, r)
+	pubKey := common.BigIntToPoint(curve.ScalarBaseMult(privKey.Bytes()))
+	decode := func(packed common.EncryptedBallot, options int) ([]int, error) {
+		Z := decryptWith(privKey, []common.EncryptedBallot{packed})[0]
+		M := common.BigIntToPoint(curve.Add(&packed.C2.X, &packed.C2.Y, &Z.X, new(big.Int).Sub(curve.Params().P, &Z.Y)))
+		return DecodeRanking(M, options, curve)
+	}
+
+	// option 2 leads the first round but 1 wins once 0 is eliminated
+	rankings := [][]int{{2, 0, 1}, {2, 1, 0}, {1, 0, 2}, {1, 2, 0}, {0, 1, 2}}
+	decrypted := lo.Map(rankings, func(ranking []int, _ int) []int {
+		decoded, err := decode(EncryptRanking(ranking, election, pubKey, curve, r).PackedRanking(curve), 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return decoded
+	})
+	if !reflect.DeepEqual(decrypted, rankings) {
+		t.Errorf("Expected rankings %v, got %v", rankings, decrypted)
+	}
+	winner, rounds := InstantRunoff(decrypted, 3)
+	if winner != 1 || !reflect.DeepEqual(rounds, [][]int{{1, 2, 2}, {0, 3, 2}}) {
+		t.Errorf("Expected option 1 to win in rounds [[1 2 2] [0 3 2]], got %d in %v", winner, rounds)
+	}
+
+	// two options at the same position: 0 + 0*3 + 2*9
+	duplicated, _ := encrypt(common.BigIntToPoint(curve.ScalarMult(&H0.X, &H0.Y, big.NewInt(18).Bytes())), pubKey, curve, r)
+	if _, err := decode(duplicated, 3); !errors.Is(err, ErrInvalidRanking) {
+		t.Errorf("Expected ErrInvalidRanking, got %v", err)
+	}
+	// a value beyond n^n
+	if _, err := decode(EncryptRanking([]int{3, 2, 1, 0}, election, pubKey, curve, r).PackedRanking(curve), 3); !errors.Is(err, ErrInvalidRanking) {
+		t.Errorf("Expected ErrInvalidRanking, got %v", err)
+	}
+}
@@ -0,0 +1,177 @@
+package elgamal
+
+import (
+	"crypto/elliptic"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+
+	"github.com/delendum-xyz/private-voting/fdkg/common"
+	"github.com/delendum-xyz/private-voting/fdkg/utils"
+	"github.com/samber/lo"
+)
+
+// generatorTables holds, for each curve used so far, the fixed-base tables of
+// G and the option generators H0..H3, which are the same in every election.
+// G is multiplied for every ballot and gets a wider window.
+// The curve itself is the key, not its parameters, so that a wrapper such as
+// cost.CountingCurve gets tables that do their work through it.
+var generatorTables sync.Map
+
+// generatorWindow and keyWindow are the window widths of the fixed-base
+// tables of G and of the other points.
+const (
+	generatorWindow = 8
+	keyWindow       = 4
+)
+
+type curveTables struct {
+	g       *utils.FixedBase
+	options []*utils.FixedBase
+}
+
+func generators(curve elliptic.Curve) (*utils.FixedBase, []*utils.FixedBase) {
+	if tables, found := generatorTables.Load(curve); found {
+		return tables.(*curveTables).g, tables.(*curveTables).options
+	}
+	tables := &curveTables{
+		g: utils.NewFixedBase(common.BigIntToPoint(curve.Params().Gx, curve.Params().Gy), generatorWindow, curve),
+		options: lo.Map(ValidMessages(4), func(h common.Point, _ int) *utils.FixedBase {
+			return utils.NewFixedBase(h, keyWindow, curve)
+		}),
+	}
+	stored, _ := generatorTables.LoadOrStore(curve, tables)
+	return stored.(*curveTables).g, stored.(*curveTables).options
+}
+
+// optionMultiple is x*H_option, e.g. a candidate tally of x votes.
+func optionMultiple(option int, x int, curve elliptic.Curve) common.Point {
+	_, options := generators(curve)
+	return options[option].Mult(big.NewInt(int64(x)))
+}
+
+// bases multiplies G and the voting key for encryption and ballot proofs.
+type bases interface {
+	key() common.Point
+	mulG(k *big.Int) common.Point
+	mulKey(k *big.Int) common.Point
+}
+
+// keyBases multiplies the voting key from scratch, for one-off use.
+type keyBases struct {
+	encryptionKey common.Point
+	curve         elliptic.Curve
+}
+
+func (b keyBases) key() common.Point {
+	return b.encryptionKey
+}
+
+func (b keyBases) mulG(k *big.Int) common.Point {
+	g, _ := generators(b.curve)
+	return g.Mult(k)
+}
+
+func (b keyBases) mulKey(k *big.Int) common.Point {
+	return common.BigIntToPoint(b.curve.ScalarMult(&b.encryptionKey.X, &b.encryptionKey.Y, k.Bytes()))
+}
+
+// Tables hold the fixed-base table of an election's voting key, next to those
+// of G and H0..H3. They are kept per key and reused to encrypt and verify
+// many ballots: a voter client encrypting and proving a ballot, or a tally
+// verifying thousands, then does no doublings. The key table costs about ten
+// scalar multiplications, so it is only built once the key has been
+// multiplied keyTableUses times.
+type Tables struct {
+	EncryptionKey common.Point
+	curve         elliptic.Curve
+	// uses counts the multiplications by the key, up to keyTableUses
+	uses     int32
+	build    sync.Once
+	keyTable *utils.FixedBase
+}
+
+const keyTableUses = 16
+
+// maxKeyTables bounds the number of voting keys whose tables are kept.
+const maxKeyTables = 16
+
+// keyTables keeps the tables of the last voting keys used, keyed by curve and
+// key, oldest first in order.
+var keyTables = struct {
+	sync.Mutex
+	tables map[tablesKey]*Tables
+	order  []tablesKey
+}{tables: make(map[tablesKey]*Tables)}
+
+type tablesKey struct {
+	curve elliptic.Curve
+	key   string
+}
+
+// NewTables returns the tables of the voting key, the same for every call
+// with a key used lately.
+func NewTables(encryptionKey common.Point, curve elliptic.Curve) *Tables {
+	id := tablesKey{curve, string(encryptionKey.Marshal(curve))}
+	keyTables.Lock()
+	defer keyTables.Unlock()
+	if tables, found := keyTables.tables[id]; found {
+		return tables
+	}
+	tables := &Tables{EncryptionKey: encryptionKey, curve: curve}
+	if len(keyTables.order) == maxKeyTables {
+		delete(keyTables.tables, keyTables.order[0])
+		keyTables.order = keyTables.order[1:]
+	}
+	keyTables.tables[id] = tables
+	keyTables.order = append(keyTables.order, id)
+	return tables
+}
+
+func (t *Tables) key() common.Point {
+	return t.EncryptionKey
+}
+
+func (t *Tables) mulG(k *big.Int) common.Point {
+	g, _ := generators(t.curve)
+	return g.Mult(k)
+}
+
+func (t *Tables) mulKey(k *big.Int) common.Point {
+	if atomic.LoadInt32(&t.uses) < keyTableUses && atomic.AddInt32(&t.uses, 1) <= keyTableUses {
+		return common.BigIntToPoint(t.curve.ScalarMult(&t.EncryptionKey.X, &t.EncryptionKey.Y, k.Bytes()))
+	}
+	t.build.Do(func() {
+		t.keyTable = utils.NewFixedBase(t.EncryptionKey, keyWindow, t.curve)
+	})
+	return t.keyTable.Mult(k)
+}
+
+// EncryptBallot is EncryptBallot under the tables' key.
+func (t *Tables) EncryptBallot(vote int, options int, r *rand.Rand) common.EncryptedBallot {
+	if vote < 0 || vote > options-1 {
+		panic(fmt.Sprintf("Invalid vote: %v, must be between 0 and %v", vote, options-1))
+	}
+	if options < 2 {
+		panic("There must be at least 2 options")
+	}
+	ballot, _ := encryptWith(t, ValidMessages(options)[vote], t.curve, r)
+	return ballot
+}
+
+// EncryptBallotWithProof is EncryptBallotWithProof under the tables' key.
+func (t *Tables) EncryptBallotWithProof(vote int, options int, election common.Hash, r *rand.Rand) (common.EncryptedBallot, BallotProof) {
+	messages := ValidMessages(options)
+	if vote < 0 || vote >= len(messages) {
+		panic("Invalid vote")
+	}
+	ballot, k := encryptWith(t, messages[vote], t.curve, r)
+	return ballot, proveOneOfWith(t, ballot, k, messages, vote, election, t.curve, r)
+}
+
+// VerifyBallot is BallotProof.Verify under the tables' key.
+func (t *Tables) VerifyBallot(ballot common.EncryptedBallot, proof BallotProof, options int, election common.Hash) bool {
+	return proof.verifyOneOfWith(t, ballot, ValidMessages(options), election, t.curve)
+}
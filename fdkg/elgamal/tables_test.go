@@ -0,0 +1,106 @@
+package elgamal
+
+import (
+	"crypto/elliptic"
+	"math/big"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/delendum-xyz/private-voting/fdkg/common"
+	"github.com/delendum-xyz/private-voting/fdkg/utils"
+)
+
+func TestTablesMatchDirectEncryption(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	privKey := utils.RandomBigInt(curve, r)
+	pubKey := common.BigIntToPoint(curve.ScalarBaseMult(privKey.Bytes()))
+	tables := NewTables(pubKey, curve)
+	if NewTables(pubKey, curve) != tables {
+		t.Errorf("Expected the tables of a key to be built once")
+	}
+
+	for _, options := range []int{2, 3, 4} {
+		for vote := 0; vote < options; vote++ {
+			direct := EncryptBallot(vote, options, pubKey, curve, rand.New(rand.NewSource(int64(vote))))
+			if ballot := tables.EncryptBallot(vote, options, rand.New(rand.NewSource(int64(vote)))); !reflect.DeepEqual(ballot, direct) {
+				t.Errorf("Ballot for %d of %d options differs from EncryptBallot", vote, options)
+			}
+
+			ballot, proof := tables.EncryptBallotWithProof(vote, options, election, r)
+			if !proof.Verify(ballot, options, election, pubKey, curve) || !tables.VerifyBallot(ballot, proof, options, election) {
+				t.Errorf("Valid proof for %d of %d options rejected", vote, options)
+			}
+			if tables.VerifyBallot(ballot, proof, options, common.Hash{2}) {
+				t.Errorf("Proof for %d of %d options accepted in another election", vote, options)
+			}
+		}
+	}
+}
+
+// countingCurve counts scalar multiplications like cost.CountingCurve, which
+// cannot be imported here.
+type countingCurve struct {
+	elliptic.Curve
+	scalarMults int
+}
+
+func (c *countingCurve) ScalarMult(x, y *big.Int, k []byte) (*big.Int, *big.Int) {
+	c.scalarMults++
+	return c.Curve.ScalarMult(x, y, k)
+}
+
+func (c *countingCurve) ScalarBaseMult(k []byte) (*big.Int, *big.Int) {
+	c.scalarMults++
+	return c.Curve.ScalarBaseMult(k)
+}
+
+func (c *countingCurve) CountScalarMult() {
+	c.scalarMults++
+}
+
+func TestTablesPerCurve(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	privKey := utils.RandomBigInt(curve, r)
+	pubKey := common.BigIntToPoint(curve.ScalarBaseMult(privKey.Bytes()))
+	EncryptBallot(1, 3, pubKey, curve, r)
+
+	// the plain curve built its tables first; a meter still sees every
+	// multiplication, and its tables do not count into other meters
+	first, second := &countingCurve{Curve: curve}, &countingCurve{Curve: curve}
+	EncryptBallot(1, 3, pubKey, first, r)
+	counted := first.scalarMults
+	if counted != 2 {
+		t.Errorf("Expected k*G and k*Y for a ballot, counted %d scalar multiplications", counted)
+	}
+	EncryptBallot(1, 3, pubKey, second, r)
+	optionMultiple(2, 5, second)
+	if first.scalarMults != counted {
+		t.Errorf("Another meter's ballot was counted into the first")
+	}
+	if second.scalarMults != counted+1 {
+		t.Errorf("Expected %d scalar multiplications, counted %d", counted+1, second.scalarMults)
+	}
+}
+
+func BenchmarkEncryptBallot(b *testing.B) {
+	r := rand.New(rand.NewSource(0))
+	privKey := utils.RandomBigInt(curve, r)
+	pubKey := common.BigIntToPoint(curve.ScalarBaseMult(privKey.Bytes()))
+	tables := NewTables(pubKey, curve)
+	b.Run("Tables", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			tables.EncryptBallot(1, 2, r)
+		}
+	})
+	b.Run("ScalarMult", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			k := utils.RandomBigInt(curve, r)
+			C1 := common.BigIntToPoint(curve.ScalarBaseMult(k.Bytes()))
+			X, Y := curve.ScalarMult(&pubKey.X, &pubKey.Y, k.Bytes())
+			H := ValidMessages(2)[1]
+			curve.Add(X, Y, &H.X, &H.Y)
+			_ = C1
+		}
+	})
+}
@@ -0,0 +1,163 @@
+package elgamal
+
+import (
+	"crypto/elliptic"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"math/rand"
+
+	"github.com/delendum-xyz/private-voting/fdkg/common"
+	"github.com/delendum-xyz/private-voting/fdkg/schnorr"
+	"github.com/delendum-xyz/private-voting/fdkg/utils"
+	"github.com/samber/lo"
+)
+
+var (
+	ErrUncertifiedWeight = errors.New("weight is not certified")
+	ErrWeightedOptions   = errors.New("weighted ballots have two to four options")
+)
+
+// WeightCertificate is the registrar's Schnorr signature on a voter's weight.
+type WeightCertificate struct {
+	Voter     int
+	Weight    int
+	Signature schnorr.Signature
+}
+
+// weightMessage is what the registrar signs: the voter's weight in this
+// election, so that a certificate does not carry over to another one.
+func weightMessage(election common.Hash, voter, weight int) []byte {
+	message := make([]byte, 16)
+	binary.BigEndian.PutUint64(message, uint64(voter))
+	binary.BigEndian.PutUint64(message[8:], uint64(weight))
+	return append(append([]byte("fdkg/weight"), election[:]...), message...)
+}
+
+// CertifyWeight is run by the registrar holding registrarKey.
+func CertifyWeight(voter, weight int, election common.Hash, registrarKey big.Int, curve elliptic.Curve) WeightCertificate {
+	return WeightCertificate{Voter: voter, Weight: weight, Signature: schnorr.Sign(registrarKey, weightMessage(election, voter, weight), curve)}
+}
+
+func (c WeightCertificate) Verify(registrar common.Point, election common.Hash, curve elliptic.Curve) bool {
+	return c.Weight > 0 && schnorr.Verify(registrar, weightMessage(election, c.Voter, c.Weight), c.Signature, curve)
+}
+
+// WeightTable maps voters to weights fixed in advance, e.g. a stake snapshot.
+type WeightTable map[int]int
+
+// Weight looks up the voter's weight; voters absent from the table have none.
+func (t WeightTable) Weight(voter int) (int, error) {
+	if w, found := t[voter]; found && w > 0 {
+		return w, nil
+	}
+	return 0, fmt.Errorf("%w: voter %d not in the weight table", ErrUncertifiedWeight, voter)
+}
+
+// Total is the sum of all weights, the largest count any option can reach.
+func (t WeightTable) Total() int {
+	return lo.Sum(lo.Values(t))
+}
+
+// WeightedMessages are the valid messages scaled by the weight: w*m for every
+// m in ValidMessages(options).
+func WeightedMessages(options, weight int, curve elliptic.Curve) []common.Point {
+	w := big.NewInt(int64(weight)).Bytes()
+	return lo.Map(ValidMessages(options), func(m common.Point, _ int) common.Point {
+		if m.X.Sign() == 0 && m.Y.Sign() == 0 {
+			return m
+		}
+		return common.BigIntToPoint(curve.ScalarMult(&m.X, &m.Y, w))
+	})
+}
+
+// weightedContext binds the proof of a weighted ballot to the election and to
+// the voter and weight it was cast with, so that it verifies for no other
+// voter or weight.
+func weightedContext(election common.Hash, voter, weight int) common.Hash {
+	var context common.Hash
+	copy(context[:], utils.Keccak256(weightMessage(election, voter, weight)))
+	return context
+}
+
+// EncryptWeightedBallot encrypts the vote scaled by the weight, weight*vote*H0
+// for a yes/no vote and weight*H_vote otherwise, and proves the ballot
+// encrypts one of the weighted messages, for this voter and weight.
+func EncryptWeightedBallot(vote, options, voter, weight int, election common.Hash, encryptionKey common.Point, curve elliptic.Curve, r *rand.Rand) (common.EncryptedBallot, BallotProof) {
+	if options < 2 || options > 4 || vote < 0 || vote >= options || weight < 1 {
+		panic(fmt.Sprintf("Invalid weighted vote: %v of %v options with weight %v", vote, options, weight))
+	}
+	messages := WeightedMessages(options, weight, curve)
+	ballot, k := encrypt(messages[vote], encryptionKey, curve, r)
+	return ballot, proveOneOf(ballot, k, messages, vote, weightedContext(election, voter, weight), encryptionKey, curve, r)
+}
+
+// VerifyWeighted checks the proof of a ballot cast by the voter with the given
+// weight, taken from the weight table.
+func (p BallotProof) VerifyWeighted(ballot common.EncryptedBallot, options, voter, weight int, election common.Hash, encryptionKey common.Point, curve elliptic.Curve) bool {
+	return options >= 2 && options <= 4 && weight > 0 && p.verifyOneOf(ballot, WeightedMessages(options, weight, curve), weightedContext(election, voter, weight), encryptionKey, curve)
+}
+
+// VerifyCertified checks the certificate against the registrar's key and the
+// proof of the ballot against the certified voter and weight.
+func (p BallotProof) VerifyCertified(ballot common.EncryptedBallot, options int, certificate WeightCertificate, registrar common.Point, election common.Hash, encryptionKey common.Point, curve elliptic.Curve) bool {
+	return certificate.Verify(registrar, election, curve) && p.VerifyWeighted(ballot, options, certificate.Voter, certificate.Weight, election, encryptionKey, curve)
+}
+
+// DecryptWeightedResults decodes the sum of weighted ballots whose weights add
+// up to at most totalWeight: the yes count of yes/no ballots, by baby-step
+// giant-step in O(sqrt(totalWeight)), or the weight of every option, in
+// O(totalWeight^(options-1)).
+func DecryptWeightedResults(Z, C2 common.Point, totalWeight, options int, curve elliptic.Curve) ([]int, error) {
+	if options < 2 || options > 4 {
+		return nil, fmt.Errorf("%w: got %d", ErrWeightedOptions, options)
+	}
+	negZ := negate(Z, curve)
+	M := common.BigIntToPoint(curve.Add(&C2.X, &C2.Y, &negZ.X, &negZ.Y))
+	if options == 2 {
+		x, found := newDlogTable(common.Point{X: H0.X, Y: H0.Y}, totalWeight, curve).solve(M, totalWeight)
+		if !found {
+			return nil, fmt.Errorf("%w: [0, %d]", ErrNoDiscreteLog, totalWeight)
+		}
+		return []int{x}, nil
+	}
+	if counts, found := solveOptions(M, options, totalWeight, curve); found {
+		return counts, nil
+	}
+	return nil, fmt.Errorf("%w: %d options up to %d in total", ErrNoDiscreteLog, options, totalWeight)
+}
+
+// solveOptions finds x_0*H0 + ... + x_(n-1)*H_(n-1) = M for the n options with
+// the x adding up to at most max. It stores x_0*H0 for every x_0 and walks the
+// counts of the other options, looking up what is left of M.
+func solveOptions(M common.Point, options, max int, curve elliptic.Curve) ([]int, bool) {
+	generators := ValidMessages(options)
+	x0s := make(map[string]int, max+1)
+	step := common.PointZero()
+	for x := 0; x <= max; x++ {
+		x0s[pointKey(step)] = x
+		step = common.BigIntToPoint(curve.Add(&step.X, &step.Y, &generators[0].X, &generators[0].Y))
+	}
+	counts := make([]int, options)
+	// walk tries the counts of option and the following ones, rest being M
+	// minus the options before
+	var walk func(option, left int, rest common.Point) bool
+	walk = func(option, left int, rest common.Point) bool {
+		if option == options {
+			x0, found := x0s[pointKey(rest)]
+			counts[0] = x0
+			return found && x0 <= left
+		}
+		h := negate(generators[option], curve)
+		for x := 0; x <= left; x++ {
+			counts[option] = x
+			if walk(option+1, left-x, rest) {
+				return true
+			}
+			rest = common.BigIntToPoint(curve.Add(&rest.X, &rest.Y, &h.X, &h.Y))
+		}
+		return false
+	}
+	return counts, walk(1, max, M)
+}
@@ -0,0 +1,112 @@
+package elgamal
+
+import (
+	"errors"
+	"math/big"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/delendum-xyz/private-voting/fdkg/common"
+	"github.com/delendum-xyz/private-voting/fdkg/utils"
+)
+
+func TestSolveDiscreteLog(t *testing.T) {
+	base := common.Point{X: H0.X, Y: H0.Y}
+	for _, x := range []int{0, 1, 99, 100, 12345, 1000000} {
+		M := common.BigIntToPoint(curve.ScalarMult(&H0.X, &H0.Y, big.NewInt(int64(x)).Bytes()))
+		found, err := SolveDiscreteLog(M, base, 1000000, curve)
+		if err != nil || found != x {
+			t.Errorf("Expected %d, got %d, %v", x, found, err)
+		}
+	}
+	M := common.BigIntToPoint(curve.ScalarMult(&H0.X, &H0.Y, big.NewInt(101).Bytes()))
+	if _, err := SolveDiscreteLog(M, base, 100, curve); !errors.Is(err, ErrNoDiscreteLog) {
+		t.Errorf("Expected ErrNoDiscreteLog, got %v", err)
+	}
+}
+
+func TestWeightedTally(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	privKey := utils.RandomBigInt(curve, r)
+	pubKey := common.BigIntToPoint(curve.ScalarBaseMult(privKey.Bytes()))
+	registrarKey := utils.RandomBigInt(curve, r)
+	registrar := common.BigIntToPoint(curve.ScalarBaseMult(registrarKey.Bytes()))
+
+	table := WeightTable{1: 250000, 2: 1200, 3: 48000, 4: 7}
+	votes := map[int]int{1: 1, 2: 0, 3: 1, 4: 1}
+	sum := common.EncryptedBallot{C1: common.PointZero(), C2: common.PointZero()}
+	for voter, vote := range votes {
+		certificate := CertifyWeight(voter, table[voter], election, registrarKey, curve)
+		if !certificate.Verify(registrar, election, curve) {
+			t.Fatalf("Valid certificate of voter %d rejected", voter)
+		}
+		ballot, proof := EncryptWeightedBallot(vote, 2, voter, certificate.Weight, election, pubKey, curve, r)
+		if !proof.VerifyCertified(ballot, 2, certificate, registrar, election, pubKey, curve) {
+			t.Errorf("Valid weighted ballot of voter %d rejected", voter)
+		}
+		if proof.VerifyWeighted(ballot, 2, voter, certificate.Weight+1, election, pubKey, curve) {
+			t.Errorf("Weighted ballot of voter %d accepted with another weight", voter)
+		}
+		other := CertifyWeight(voter%4+1, certificate.Weight, election, registrarKey, curve)
+		if proof.VerifyCertified(ballot, 2, other, registrar, election, pubKey, curve) {
+			t.Errorf("Weighted ballot of voter %d accepted with another voter's certificate", voter)
+		}
+		sum = addBallots(sum, ballot, curve)
+	}
+	forged := CertifyWeight(2, 1200, election, registrarKey, curve)
+	if forged.Verify(registrar, common.Hash{2}, curve) {
+		t.Errorf("Certificate accepted in another election")
+	}
+	forged.Weight = 1201
+	if forged.Verify(registrar, election, curve) {
+		t.Errorf("Certificate with a changed weight accepted")
+	}
+	if _, err := table.Weight(5); !errors.Is(err, ErrUncertifiedWeight) {
+		t.Errorf("Expected ErrUncertifiedWeight, got %v", err)
+	}
+
+	Z := common.BigIntToPoint(curve.ScalarMult(&sum.C1.X, &sum.C1.Y, privKey.Bytes()))
+	result, err := DecryptWeightedResults(Z, sum.C2, table.Total(), 2, curve)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []int{298007}; !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %v, got %v", expected, result)
+	}
+}
+
+func TestWeightedMultiOptionTally(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	privKey := utils.RandomBigInt(curve, r)
+	pubKey := common.BigIntToPoint(curve.ScalarBaseMult(privKey.Bytes()))
+
+	table := WeightTable{1: 7, 2: 12, 3: 3, 4: 5, 5: 2}
+	votes := map[int]int{1: 2, 2: 0, 3: 3, 4: 2, 5: 1}
+	sum := common.EncryptedBallot{C1: common.PointZero(), C2: common.PointZero()}
+	for voter, vote := range votes {
+		ballot, proof := EncryptWeightedBallot(vote, 4, voter, table[voter], election, pubKey, curve, r)
+		if !proof.VerifyWeighted(ballot, 4, voter, table[voter], election, pubKey, curve) {
+			t.Errorf("Valid weighted ballot of voter %d rejected", voter)
+		}
+		if proof.VerifyWeighted(ballot, 4, voter, table[voter]+1, election, pubKey, curve) {
+			t.Errorf("Weighted ballot of voter %d accepted with another weight", voter)
+		}
+		if proof.VerifyWeighted(ballot, 3, voter, table[voter], election, pubKey, curve) {
+			t.Errorf("Weighted ballot of voter %d accepted with three options", voter)
+		}
+		sum = addBallots(sum, ballot, curve)
+	}
+
+	Z := common.BigIntToPoint(curve.ScalarMult(&sum.C1.X, &sum.C1.Y, privKey.Bytes()))
+	result, err := DecryptWeightedResults(Z, sum.C2, table.Total(), 4, curve)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []int{12, 2, 12, 3}; !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %v, got %v", expected, result)
+	}
+	if _, err := DecryptWeightedResults(Z, sum.C2, table.Total(), 5, curve); !errors.Is(err, ErrWeightedOptions) {
+		t.Errorf("Expected ErrWeightedOptions, got %v", err)
+	}
+}
@@ -0,0 +1,156 @@
+// Package eligibility is the voter registry of an election: a Merkle tree over
+// the eligible voters' public keys whose root is published in the election
+// parameters, and the membership proofs ballots carry to show their voter is
+// in it.
+package eligibility
+
+import (
+	"crypto/elliptic"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/delendum-xyz/private-voting/fdkg/common"
+	"github.com/delendum-xyz/private-voting/fdkg/schnorr"
+	"github.com/delendum-xyz/private-voting/fdkg/utils"
+)
+
+var (
+	ErrNotEligible       = errors.New("voter is not eligible")
+	ErrInvalidMembership = errors.New("invalid membership proof")
+)
+
+// Leaf hashes a public key, domain separated from the inner nodes.
+func Leaf(publicKey common.Point, curve elliptic.Curve) common.Hash {
+	return toHash(utils.Keccak256([]byte{0}, publicKey.Marshal(curve)))
+}
+
+func node(left, right common.Hash) common.Hash {
+	return toHash(utils.Keccak256([]byte{1}, left[:], right[:]))
+}
+
+func toHash(b []byte) common.Hash {
+	var h common.Hash
+	copy(h[:], b)
+	return h
+}
+
+// Tree is a Merkle tree over voter public keys, padded with zero leaves to a
+// power of two.
+type Tree struct {
+	curve  elliptic.Curve
+	levels [][]common.Hash
+	index  map[common.Hash]int
+}
+
+func NewTree(publicKeys []common.Point, curve elliptic.Curve) (*Tree, error) {
+	if len(publicKeys) == 0 {
+		return nil, fmt.Errorf("%w: no voters", ErrNotEligible)
+	}
+	size := 1
+	for size < len(publicKeys) {
+		size *= 2
+	}
+	leaves := make([]common.Hash, size)
+	t := &Tree{curve: curve, index: make(map[common.Hash]int, len(publicKeys))}
+	for i, publicKey := range publicKeys {
+		leaves[i] = Leaf(publicKey, curve)
+		if _, found := t.index[leaves[i]]; found {
+			return nil, fmt.Errorf("duplicate voter key %x", publicKey.Marshal(curve))
+		}
+		t.index[leaves[i]] = i
+	}
+	t.levels = [][]common.Hash{leaves}
+	for level := leaves; len(level) > 1; {
+		next := make([]common.Hash, len(level)/2)
+		for i := range next {
+			next[i] = node(level[2*i], level[2*i+1])
+		}
+		t.levels = append(t.levels, next)
+		level = next
+	}
+	return t, nil
+}
+
+func (t *Tree) Root() common.Hash {
+	return t.levels[len(t.levels)-1][0]
+}
+
+// Proof shows PublicKey is the leaf at Index of the tree: hashing it up with
+// the Siblings on the path gives the root.
+type Proof struct {
+	PublicKey common.Point
+	Index     int
+	Siblings  []common.Hash
+}
+
+func (t *Tree) Prove(publicKey common.Point) (Proof, error) {
+	index, found := t.index[Leaf(publicKey, t.curve)]
+	if !found {
+		return Proof{}, ErrNotEligible
+	}
+	proof := Proof{PublicKey: publicKey, Index: index}
+	for i, level := range t.levels[:len(t.levels)-1] {
+		proof.Siblings = append(proof.Siblings, level[(index>>i)^1])
+	}
+	return proof, nil
+}
+
+func (p Proof) Verify(root common.Hash, curve elliptic.Curve) bool {
+	if p.Index < 0 || p.Index >= 1<<len(p.Siblings) || !p.PublicKey.IsOnCurve(curve) {
+		return false
+	}
+	h := Leaf(p.PublicKey, curve)
+	for i, sibling := range p.Siblings {
+		if (p.Index>>i)&1 == 0 {
+			h = node(h, sibling)
+		} else {
+			h = node(sibling, h)
+		}
+	}
+	return h == root
+}
+
+// Membership is the proof a ballot carries: the voter's key is in the tree
+// and the voter signed this ballot under this nullifier in this election, so
+// the proof cannot be copied onto another ballot or into another election.
+type Membership struct {
+	Proof
+	Signature schnorr.Signature
+}
+
+func ballotMessage(election common.Hash, nullifier common.Nullifier, ballot common.EncryptedBallot, curve elliptic.Curve) []byte {
+	return utils.Keccak256([]byte("fdkg/eligible-ballot"), election[:], nullifier[:], ballot.C1.Marshal(curve), ballot.C2.Marshal(curve))
+}
+
+// SignBallot signs the ballot cast under nullifier in the election, binding
+// the nullifier to the voter's key.
+func SignBallot(privateKey big.Int, election common.Hash, nullifier common.Nullifier, ballot common.EncryptedBallot, curve elliptic.Curve) schnorr.Signature {
+	return schnorr.Sign(privateKey, ballotMessage(election, nullifier, ballot, curve), curve)
+}
+
+// VerifyBallotSignature checks a signature made with SignBallot.
+func VerifyBallotSignature(publicKey common.Point, election common.Hash, nullifier common.Nullifier, ballot common.EncryptedBallot, signature schnorr.Signature, curve elliptic.Curve) bool {
+	return schnorr.Verify(publicKey, ballotMessage(election, nullifier, ballot, curve), signature, curve)
+}
+
+// NewMembership proves the voter holding privateKey is eligible and signs the
+// ballot.
+func NewMembership(tree *Tree, privateKey big.Int, election common.Hash, nullifier common.Nullifier, ballot common.EncryptedBallot, curve elliptic.Curve) (Membership, error) {
+	proof, err := tree.Prove(common.BigIntToPoint(curve.ScalarBaseMult(privateKey.Bytes())))
+	if err != nil {
+		return Membership{}, err
+	}
+	return Membership{Proof: proof, Signature: SignBallot(privateKey, election, nullifier, ballot, curve)}, nil
+}
+
+// Verify checks the membership of the ballot's voter against root.
+func (m Membership) Verify(root, election common.Hash, nullifier common.Nullifier, ballot common.EncryptedBallot, curve elliptic.Curve) error {
+	if !m.Proof.Verify(root, curve) {
+		return fmt.Errorf("%w: key not in the registry", ErrInvalidMembership)
+	}
+	if !VerifyBallotSignature(m.PublicKey, election, nullifier, ballot, m.Signature, curve) {
+		return fmt.Errorf("%w: ballot not signed by the eligible key", ErrInvalidMembership)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package eligibility
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/delendum-xyz/private-voting/fdkg/common"
+	"github.com/delendum-xyz/private-voting/fdkg/utils"
+	"github.com/torusresearch/pvss/secp256k1"
+)
+
+var curve = secp256k1.Curve
+
+func randomKeys(n int, r *rand.Rand) []common.Point {
+	keys := make([]common.Point, n)
+	for i := range keys {
+		k := utils.RandomBigInt(curve, r)
+		keys[i] = common.BigIntToPoint(curve.ScalarBaseMult(k.Bytes()))
+	}
+	return keys
+}
+
+func TestMerkleProofs(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	for _, n := range []int{1, 2, 5, 8} {
+		keys := randomKeys(n, r)
+		tree, err := NewTree(keys, curve)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, key := range keys {
+			proof, err := tree.Prove(key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if proof.Index != i || !proof.Verify(tree.Root(), curve) {
+				t.Errorf("n=%d: valid proof of voter %d rejected", n, i)
+			}
+			if n > 1 {
+				moved := proof
+				moved.Index ^= 1
+				if moved.Verify(tree.Root(), curve) {
+					t.Errorf("n=%d: proof accepted at another index", n)
+				}
+			}
+		}
+		outsider := randomKeys(1, r)[0]
+		if _, err := tree.Prove(outsider); !errors.Is(err, ErrNotEligible) {
+			t.Errorf("n=%d: expected ErrNotEligible, got %v", n, err)
+		}
+		forged, _ := tree.Prove(keys[0])
+		forged.PublicKey = outsider
+		if forged.Verify(tree.Root(), curve) {
+			t.Errorf("n=%d: proof accepted for another key", n)
+		}
+	}
+	keys := randomKeys(2, r)
+	if _, err := NewTree(append(keys, keys[0]), curve); err == nil {
+		t.Errorf("Expected an error for a duplicate voter key")
+	}
+}
@@ -4,10 +4,13 @@ import (
 	"fmt"
 	"math/big"
 	"math/rand"
+	"reflect"
 	"testing"
 
 	"github.com/delendum-xyz/private-voting/fdkg/common"
+	"github.com/delendum-xyz/private-voting/fdkg/election"
 	"github.com/delendum-xyz/private-voting/fdkg/elgamal"
+	"github.com/delendum-xyz/private-voting/fdkg/eligibility"
 	"github.com/delendum-xyz/private-voting/fdkg/pki"
 	"github.com/delendum-xyz/private-voting/fdkg/sss"
 	"github.com/delendum-xyz/private-voting/fdkg/utils"
@@ -27,7 +30,10 @@ func TestNewLocalParty(t *testing.T) {
 	for i := 0; i < ITERATIONS; i++ {
 		r := rand.New(rand.NewSource(int64(i)))
 		n_dkg := 6
-		localNodes, dkgNodes := pki.GenerateSetOfNodes(config, n_dkg, curve, r)
+		localNodes, dkgNodes, err := pki.GenerateSetOfNodes(config, n_dkg, pki.UniformSelector{}, curve, r)
+		if err != nil {
+			t.Fatal(err)
+		}
 		if len(localNodes) != config.Size {
 			t.Errorf("Expected %d nodes, got %d", config.Size, len(localNodes))
 		}
@@ -78,7 +84,10 @@ func TestCorrectSharingAndReconstruction(t *testing.T) {
 	for i := 0; i < ITERATIONS; i++ {
 		r := rand.New(rand.NewSource(int64(i)))
 		n_dkg := 6
-		localNodes, dkgNodes := pki.GenerateSetOfNodes(config, n_dkg, curve, r)
+		localNodes, dkgNodes, err := pki.GenerateSetOfNodes(config, n_dkg, pki.UniformSelector{}, curve, r)
+		if err != nil {
+			t.Fatal(err)
+		}
 		// generate shares for each node
 		receiverToShares := make(map[int][]sss.Share)
 		senderToShares := make(map[int][]sss.Share)
@@ -190,7 +199,7 @@ func TestPartialDecryptionOfTwoDkgNodesAndThreeGuardiansAndOneVote(t *testing.T)
 		}
 
 		// voting
-		votes := Voting([]pki.LocalParty{alice, bob, carol_local, dave_local, eve_local}, votingPubKey, curve, r)
+		votes := election.Voting([]pki.LocalParty{alice, bob, carol_local, dave_local, eve_local}, votingPubKey, curve, r)
 		C1s := utils.Map(votes, func(vote common.EncryptedBallot) common.Point { return vote.C1 })
 
 		// online tally
@@ -283,7 +292,7 @@ func TestPartialDecryptionOfOneDkgNodeAndTwoGuardiansOneVote(t *testing.T) {
 		}
 
 		// voting
-		votes := Voting([]pki.LocalParty{alice}, votingPubKey, curve, r)
+		votes := election.Voting([]pki.LocalParty{alice}, votingPubKey, curve, r)
 		C1s := utils.Map(votes, func(vote common.EncryptedBallot) common.Point { return vote.C1 })
 
 		// online tally
@@ -350,7 +359,7 @@ func TestPartialDecryptionOfOneDkgNodeAndTwoGuardiansAndManyVotes(t *testing.T)
 		}
 		alicePrimaryShares := utils.Map(aliceShares, func(s sss.Share) common.PrimaryShare { return s.ToPrimaryShare() })
 
-		votingPubKey := VotingPublicKey([]pki.DkgParty{aliceDkg})
+		votingPubKey := election.VotingPublicKey([]pki.DkgParty{aliceDkg}, curve)
 		if votingPubKey.X.Cmp(&alice.VotingPublicKey.X) != 0 || votingPubKey.Y.Cmp(&alice.VotingPublicKey.Y) != 0 {
 			t.Errorf("Voting public key should be just alice voting public key as she is the only one in the DKG")
 		}
@@ -433,8 +442,11 @@ func TestVotingKeysSharing(t *testing.T) {
 	for i := 0; i < ITERATIONS; i++ {
 		r := rand.New(rand.NewSource(int64(i)))
 		n_dkg := 6
-		_, dkgNodes := pki.GenerateSetOfNodes(config, n_dkg, curve, r)
-		encryptionKey := VotingPublicKey(dkgNodes)
+		_, dkgNodes, err := pki.GenerateSetOfNodes(config, n_dkg, pki.UniformSelector{}, curve, r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		encryptionKey := election.VotingPublicKey(dkgNodes, curve)
 
 		decryptionKey := dkgNodes[0].VotingPrivKeyShare
 		for _, node := range dkgNodes[1:] {
@@ -463,8 +475,11 @@ func TestVoting(t *testing.T) {
 	for i := 0; i < ITERATIONS; i++ {
 		r := rand.New(rand.NewSource(int64(i)))
 		n_dkg := 1
-		localNodes, dkgNodes := pki.GenerateSetOfNodes(config, n_dkg, curve, r)
-		encryptionKey := VotingPublicKey(dkgNodes)
+		localNodes, dkgNodes, err := pki.GenerateSetOfNodes(config, n_dkg, pki.UniformSelector{}, curve, r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		encryptionKey := election.VotingPublicKey(dkgNodes, curve)
 
 		if len(dkgNodes) != n_dkg {
 			t.Errorf("Expected %d nodes, got %d", n_dkg, len(dkgNodes))
@@ -473,7 +488,7 @@ func TestVoting(t *testing.T) {
 			t.Errorf("Expected encryption key to be equal to voting public key share, got %v", encryptionKey)
 		}
 
-		votes := Voting(localNodes, encryptionKey, curve, r)
+		votes := election.Voting(localNodes, encryptionKey, curve, r)
 
 		C1s := utils.Map(votes, func(vote common.EncryptedBallot) common.Point { return vote.C1 })
 
@@ -566,3 +581,56 @@ func TestVoting(t *testing.T) {
 		}
 	}
 }
+
+func TestRevotingCountsLatestBallot(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	config := common.VotingConfig{
+		Size:          5,
+		Options:       2,
+		Threshold:     2,
+		GuardiansSize: 3,
+	}
+	localNodes, dkgNodes, err := pki.GenerateSetOfNodes(config, 5, pki.UniformSelector{}, curve, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shares := make(election.PartyIndexToShares)
+	for _, node := range dkgNodes {
+		for _, share := range node.GenerateShares(curve) {
+			shares[share.To] = append(shares[share.To], share)
+		}
+	}
+	encryptionKey := election.VotingPublicKey(dkgNodes, curve)
+
+	// every party votes no, then two of them change their mind
+	var casts []pki.CastBallot
+	for _, node := range append(localNodes, localNodes[1], localNodes[3]) {
+		vote := 0
+		if len(casts) >= len(localNodes) {
+			vote = 1
+		}
+		casts = append(casts, node.Cast(elgamal.EncryptSingleCandidate(vote, encryptionKey, curve, r), curve, r))
+	}
+	if localNodes[0].Nullifier() == localNodes[1].Nullifier() {
+		t.Fatalf("Two parties derived the same nullifier")
+	}
+	// a party replaying another's public nullifier, or inventing a new one
+	replayed := localNodes[2].Cast(elgamal.EncryptSingleCandidate(0, encryptionKey, curve, r), curve, r)
+	replayed.Nullifier = localNodes[1].Nullifier()
+	replayed.Signature = eligibility.SignBallot(localNodes[2].PrivateKey, config.ElectionID(), replayed.Nullifier, replayed.Ballot, curve)
+	forged := casts[0]
+	forged.Nullifier = common.Nullifier{1}
+	casts = append(casts, replayed, forged)
+
+	votes, rejected := election.LatestBallots(casts, config.ElectionID(), curve)
+	if !reflect.DeepEqual(rejected, []int{len(casts) - 2, len(casts) - 1}) {
+		t.Errorf("Expected the replayed and forged nullifiers to be rejected, got %v", rejected)
+	}
+	if len(votes) != len(localNodes) {
+		t.Fatalf("Expected %d counted ballots, got %d", len(localNodes), len(votes))
+	}
+	results := election.OfflineTally(votes, election.OnlineTally(votes, shares, curve), config, curve)
+	if results[0] != 2 {
+		t.Errorf("Expected 2 yes votes, got %v", results)
+	}
+}
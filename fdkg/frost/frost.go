@@ -0,0 +1,239 @@
+// Package frost signs with the FDKG voting key: FROST two-round threshold
+// Schnorr signatures (Komlo and Goldberg, SAC 2020) whose result is an
+// ordinary schnorr.Signature under the sum of the talliers' voting keys.
+//
+// The group secret is the sum of the talliers' shares d_i, and each d_i is
+// held either by the tallier itself or, as f_i(j), by its guardians, any t_i
+// of whom can stand in for it. A signer is therefore a (dealer, holder) pair
+// and its secret counts with weight 1 when the holder is the dealer, or with
+// the guardian's Lagrange coefficient otherwise.
+package frost
+
+import (
+	"crypto/elliptic"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/delendum-xyz/private-voting/fdkg/common"
+	"github.com/delendum-xyz/private-voting/fdkg/pki"
+	"github.com/delendum-xyz/private-voting/fdkg/schnorr"
+	"github.com/delendum-xyz/private-voting/fdkg/sss"
+	"github.com/delendum-xyz/private-voting/fdkg/utils"
+	"github.com/samber/lo"
+)
+
+var (
+	ErrNotSigner             = errors.New("not a signer of this signing package")
+	ErrMissingDealer         = errors.New("no signer for dealer")
+	ErrInvalidSignatureShare = errors.New("invalid signature share")
+	ErrCommitmentMismatch    = errors.New("signing package commitment does not match the nonce")
+)
+
+// KeyShare is the secret a holder signs with for a dealer: the dealer's own
+// d_i when Holder == Dealer, or the guardian share f_i(Holder).
+type KeyShare struct {
+	Dealer int
+	Holder int
+	Secret big.Int
+}
+
+func OwnKeyShare(p pki.DkgParty) KeyShare {
+	return KeyShare{Dealer: p.Index, Holder: p.Index, Secret: p.VotingPrivKeyShare}
+}
+
+func GuardianKeyShare(share sss.Share) KeyShare {
+	return KeyShare{Dealer: share.From, Holder: share.To, Secret: share.Value}
+}
+
+// Nonce is the secret of a round-one commitment. It must be used for one
+// signature only; signing twice with it leaks the key share.
+type Nonce struct {
+	D, E big.Int
+}
+
+// Commitment is what a signer publishes in round one.
+type Commitment struct {
+	Dealer int
+	Holder int
+	D, E   common.Point
+}
+
+// SignatureShare is what a signer publishes in round two.
+type SignatureShare struct {
+	Dealer int
+	Holder int
+	Z      big.Int
+}
+
+// SigningPackage is the message and the round-one commitments the
+// coordinator sends to every signer.
+type SigningPackage struct {
+	Message     []byte
+	Commitments []Commitment
+}
+
+// GroupKey is the key signatures verify against, the FDKG voting key.
+func GroupKey(contributions []pki.DkgContribution, curve elliptic.Curve) common.Point {
+	Y := common.PointZero()
+	for _, c := range contributions {
+		Y = common.BigIntToPoint(curve.Add(&Y.X, &Y.Y, &c.VotingPublicKey.X, &c.VotingPublicKey.Y))
+	}
+	return Y
+}
+
+// Commit is round one: a fresh nonce pair and its commitment. The nonces come
+// from crypto/rand, as anyone who can predict them learns the key share from
+// the signature share.
+func Commit(share KeyShare, curve elliptic.Curve) (Nonce, Commitment) {
+	d, e := utils.RandomBigIntCrypto(curve), utils.RandomBigIntCrypto(curve)
+	return Nonce{D: d, E: e}, Commitment{
+		Dealer: share.Dealer,
+		Holder: share.Holder,
+		D:      common.BigIntToPoint(curve.ScalarBaseMult(d.Bytes())),
+		E:      common.BigIntToPoint(curve.ScalarBaseMult(e.Bytes())),
+	}
+}
+
+// signer is a participant of a signing package with its Lagrange weight and
+// binding factor.
+type signer struct {
+	Commitment
+	weight  big.Int
+	binding big.Int
+}
+
+// signers picks, for every dealer, its own commitment if there is one and
+// otherwise the first t_i of its guardians', so that every party derives the
+// same signers from the same package.
+func signers(pkg SigningPackage, contributions []pki.DkgContribution, curve elliptic.Curve) ([]signer, error) {
+	commitments := append([]Commitment{}, pkg.Commitments...)
+	sort.SliceStable(commitments, func(i, j int) bool {
+		if commitments[i].Dealer != commitments[j].Dealer {
+			return commitments[i].Dealer < commitments[j].Dealer
+		}
+		return commitments[i].Holder < commitments[j].Holder
+	})
+	var chosen []signer
+	for _, c := range contributions {
+		own, ok := lo.Find(commitments, func(m Commitment) bool { return m.Dealer == c.Index && m.Holder == c.Index })
+		if ok {
+			chosen = append(chosen, signer{Commitment: own, weight: *big.NewInt(1)})
+			continue
+		}
+		guardians := lo.UniqBy(lo.Filter(commitments, func(m Commitment, _ int) bool {
+			return m.Dealer == c.Index && lo.Contains(c.Guardians, m.Holder)
+		}), func(m Commitment) int { return m.Holder })
+		if len(guardians) < c.Threshold {
+			return nil, fmt.Errorf("%w %d: %d of %d guardians", ErrMissingDealer, c.Index, len(guardians), c.Threshold)
+		}
+		guardians = guardians[:c.Threshold]
+		X := lo.Map(guardians, func(m Commitment, _ int) int { return m.Holder })
+		for i, m := range guardians {
+			chosen = append(chosen, signer{Commitment: m, weight: *sss.LagrangeCoefficientsStartFromOne(i, 0, X, curve)})
+		}
+	}
+
+	// rho = H(dealer, holder, m, B) over the list B of the chosen commitments
+	encoded := [][]byte{[]byte("fdkg/frost/rho"), nil, pkg.Message}
+	for _, s := range chosen {
+		encoded = append(encoded, index(s.Dealer, s.Holder), s.D.Marshal(curve), s.E.Marshal(curve))
+	}
+	for i := range chosen {
+		encoded[1] = index(chosen[i].Dealer, chosen[i].Holder)
+		rho := new(big.Int).SetBytes(utils.Keccak256(encoded...))
+		chosen[i].binding = *rho.Mod(rho, curve.Params().N)
+	}
+	return chosen, nil
+}
+
+func index(dealer, holder int) []byte {
+	b := make([]byte, 16)
+	binary.BigEndian.PutUint64(b, uint64(dealer))
+	binary.BigEndian.PutUint64(b[8:], uint64(holder))
+	return b
+}
+
+// nonceCommitment is R = sum of D_s + rho_s*E_s over the signers.
+func nonceCommitment(chosen []signer, curve elliptic.Curve) common.Point {
+	R := common.PointZero()
+	for _, s := range chosen {
+		R = common.BigIntToPoint(curve.Add(&R.X, &R.Y, &s.D.X, &s.D.Y))
+		eX, eY := curve.ScalarMult(&s.E.X, &s.E.Y, s.binding.Bytes())
+		R = common.BigIntToPoint(curve.Add(&R.X, &R.Y, eX, eY))
+	}
+	return R
+}
+
+// Sign is round two: z = d + rho*e + lambda*c*x for the holder's key share.
+// The package must carry the holder's own commitment to the nonce unchanged,
+// or a coordinator swapping commitments could solve for the key share.
+func Sign(share KeyShare, nonce Nonce, pkg SigningPackage, contributions []pki.DkgContribution, curve elliptic.Curve) (SignatureShare, error) {
+	chosen, err := signers(pkg, contributions, curve)
+	if err != nil {
+		return SignatureShare{}, err
+	}
+	self, ok := lo.Find(chosen, func(s signer) bool { return s.Dealer == share.Dealer && s.Holder == share.Holder })
+	if !ok {
+		return SignatureShare{}, fmt.Errorf("%w: holder %d for dealer %d", ErrNotSigner, share.Holder, share.Dealer)
+	}
+	D := common.BigIntToPoint(curve.ScalarBaseMult(nonce.D.Bytes()))
+	E := common.BigIntToPoint(curve.ScalarBaseMult(nonce.E.Bytes()))
+	if !self.D.Equal(D) || !self.E.Equal(E) {
+		return SignatureShare{}, fmt.Errorf("%w: holder %d for dealer %d", ErrCommitmentMismatch, share.Holder, share.Dealer)
+	}
+	c := schnorr.Challenge(nonceCommitment(chosen, curve), GroupKey(contributions, curve), pkg.Message, curve)
+
+	N := curve.Params().N
+	z := new(big.Int).Mul(&self.binding, &nonce.E)
+	z.Add(z, &nonce.D)
+	x := new(big.Int).Mul(&self.weight, &c)
+	z.Add(z, x.Mul(x, &share.Secret))
+	return SignatureShare{Dealer: share.Dealer, Holder: share.Holder, Z: *z.Mod(z, N)}, nil
+}
+
+// Aggregate checks every signature share against the signer's public share,
+// d_i*G or f_i(j)*G, and sums them into a Schnorr signature under GroupKey.
+// An invalid or missing share is reported with its signer.
+func Aggregate(pkg SigningPackage, contributions []pki.DkgContribution, shares []SignatureShare, curve elliptic.Curve) (schnorr.Signature, error) {
+	chosen, err := signers(pkg, contributions, curve)
+	if err != nil {
+		return schnorr.Signature{}, err
+	}
+	R := nonceCommitment(chosen, curve)
+	c := schnorr.Challenge(R, GroupKey(contributions, curve), pkg.Message, curve)
+
+	N := curve.Params().N
+	z := new(big.Int)
+	for _, s := range chosen {
+		share, ok := lo.Find(shares, func(z SignatureShare) bool { return z.Dealer == s.Dealer && z.Holder == s.Holder })
+		if !ok {
+			return schnorr.Signature{}, fmt.Errorf("%w: holder %d for dealer %d: missing", ErrInvalidSignatureShare, s.Holder, s.Dealer)
+		}
+		contribution, _ := lo.Find(contributions, func(c pki.DkgContribution) bool { return c.Index == s.Dealer })
+		if !verifyShare(share, s, contribution, c, curve) {
+			return schnorr.Signature{}, fmt.Errorf("%w: holder %d for dealer %d", ErrInvalidSignatureShare, s.Holder, s.Dealer)
+		}
+		z.Add(z, &share.Z)
+	}
+	return schnorr.Signature{R: R, S: *z.Mod(z, N)}, nil
+}
+
+// verifyShare checks z*G == D + rho*E + lambda*c*Y for the signer's public
+// share Y.
+func verifyShare(share SignatureShare, s signer, contribution pki.DkgContribution, c big.Int, curve elliptic.Curve) bool {
+	Y := contribution.VotingPublicKey
+	if s.Holder != s.Dealer {
+		Y = contribution.ExpectedShare(s.Holder, curve)
+	}
+	lhsX, lhsY := curve.ScalarBaseMult(share.Z.Bytes())
+
+	eX, eY := curve.ScalarMult(&s.E.X, &s.E.Y, s.binding.Bytes())
+	rhsX, rhsY := curve.Add(&s.D.X, &s.D.Y, eX, eY)
+	k := new(big.Int).Mul(&s.weight, &c)
+	yX, yY := curve.ScalarMult(&Y.X, &Y.Y, k.Mod(k, curve.Params().N).Bytes())
+	rhsX, rhsY = curve.Add(rhsX, rhsY, yX, yY)
+	return lhsX.Cmp(rhsX) == 0 && lhsY.Cmp(rhsY) == 0
+}
@@ -0,0 +1,91 @@
+package frost
+
+import (
+	"errors"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/delendum-xyz/private-voting/fdkg/common"
+	"github.com/delendum-xyz/private-voting/fdkg/pki"
+	"github.com/delendum-xyz/private-voting/fdkg/schnorr"
+	"github.com/samber/lo"
+	"github.com/torusresearch/pvss/secp256k1"
+)
+
+var curve = secp256k1.Curve
+
+func TestThresholdSignature(t *testing.T) {
+	pki.Output = io.Discard
+	config := common.VotingConfig{
+		Size:          6,
+		Options:       2,
+		Threshold:     2,
+		GuardiansSize: 3,
+	}
+	r := rand.New(rand.NewSource(0))
+	_, dkgNodes, err := pki.GenerateSetOfNodes(config, 3, pki.UniformSelector{}, curve, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	contributions := lo.Map(dkgNodes, func(p pki.DkgParty, _ int) pki.DkgContribution { return p.Contribution(curve) })
+
+	// the first tallier is offline and all of its guardians sign for it
+	keyShares := lo.Map(dkgNodes[1:], func(p pki.DkgParty, _ int) KeyShare { return OwnKeyShare(p) })
+	for _, share := range dkgNodes[0].GenerateShares(curve) {
+		keyShares = append(keyShares, GuardianKeyShare(share))
+	}
+
+	message := []byte("tally certificate")
+	nonces := make([]Nonce, len(keyShares))
+	pkg := SigningPackage{Message: message}
+	for i, share := range keyShares {
+		var commitment Commitment
+		nonces[i], commitment = Commit(share, curve)
+		pkg.Commitments = append(pkg.Commitments, commitment)
+	}
+
+	var shares []SignatureShare
+	for i, share := range keyShares {
+		z, err := Sign(share, nonces[i], pkg, contributions, curve)
+		if errors.Is(err, ErrNotSigner) {
+			// only t_i of the guardians are needed
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		shares = append(shares, z)
+	}
+	if len(shares) != 2+config.Threshold {
+		t.Fatalf("Expected %d signature shares, got %d", 2+config.Threshold, len(shares))
+	}
+
+	signature, err := Aggregate(pkg, contributions, shares, curve)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !schnorr.Verify(GroupKey(contributions, curve), message, signature, curve) {
+		t.Errorf("Threshold signature does not verify as a Schnorr signature")
+	}
+
+	// a coordinator swapping a signer's commitment for another
+	swapped := SigningPackage{Message: message, Commitments: append([]Commitment{}, pkg.Commitments...)}
+	swapped.Commitments[0].D, swapped.Commitments[0].E = swapped.Commitments[1].D, swapped.Commitments[1].E
+	if _, err := Sign(keyShares[0], nonces[0], swapped, contributions, curve); !errors.Is(err, ErrCommitmentMismatch) {
+		t.Errorf("Expected ErrCommitmentMismatch, got %v", err)
+	}
+
+	shares[len(shares)-1].Z.SetInt64(1)
+	if _, err := Aggregate(pkg, contributions, shares, curve); !errors.Is(err, ErrInvalidSignatureShare) {
+		t.Errorf("Expected ErrInvalidSignatureShare, got %v", err)
+	}
+	if _, err := Aggregate(pkg, contributions, shares[:1], curve); !errors.Is(err, ErrInvalidSignatureShare) {
+		t.Errorf("Expected ErrInvalidSignatureShare for missing shares, got %v", err)
+	}
+
+	pkg.Commitments = pkg.Commitments[:2]
+	if _, err := Aggregate(pkg, contributions, shares, curve); !errors.Is(err, ErrMissingDealer) {
+		t.Errorf("Expected ErrMissingDealer, got %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+// Package hybrid encrypts arbitrary payloads to the FDKG voting key, such as
+// sealed bids, escrowed documents or disclosures opened after the election.
+// An ElGamal KEM agrees on the point S = k*Y with C1 = k*G, and AES-256-GCM
+// under a key derived from S seals the payload. Decryption needs S = x*C1,
+// which the talliers and guardians compute as for a ballot's C1.
+package hybrid
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/elliptic"
+	"errors"
+	"fmt"
+
+	"github.com/delendum-xyz/private-voting/fdkg/common"
+	"github.com/delendum-xyz/private-voting/fdkg/pki"
+	"github.com/delendum-xyz/private-voting/fdkg/utils"
+)
+
+var ErrDecryption = errors.New("decryption failed")
+
+// Ciphertext is C1 = k*G and the AEAD-sealed payload.
+type Ciphertext struct {
+	C1     common.Point
+	Sealed []byte
+}
+
+// aead derives the AES-256-GCM key from the shared point S and C1. The key is
+// fresh for every ciphertext, so the nonce can be fixed.
+func aead(S, C1 common.Point, curve elliptic.Curve) cipher.AEAD {
+	block, err := aes.NewCipher(utils.Keccak256([]byte("fdkg/hybrid/kem"), C1.Marshal(curve), S.Marshal(curve)))
+	if err != nil {
+		panic(err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		panic(err)
+	}
+	return gcm
+}
+
+// Encrypt seals the payload to encryptionKey. The election is authenticated
+// data: a ciphertext copied into another election does not decrypt. The
+// ephemeral key k comes from crypto/rand: the GCM nonce is fixed, which is
+// only safe while k never repeats, and anyone who predicts k can decrypt.
+func Encrypt(payload []byte, election common.Hash, encryptionKey common.Point, curve elliptic.Curve) Ciphertext {
+	k := utils.RandomBigIntCrypto(curve)
+	C1 := common.BigIntToPoint(curve.ScalarBaseMult(k.Bytes()))
+	S := common.BigIntToPoint(curve.ScalarMult(&encryptionKey.X, &encryptionKey.Y, k.Bytes()))
+	gcm := aead(S, C1, curve)
+	return Ciphertext{C1: C1, Sealed: gcm.Seal(nil, make([]byte, gcm.NonceSize()), payload, election[:])}
+}
+
+// Decrypt opens the ciphertext given S = x*C1.
+func Decrypt(ciphertext Ciphertext, election common.Hash, S common.Point, curve elliptic.Curve) ([]byte, error) {
+	gcm := aead(S, ciphertext.C1, curve)
+	payload, err := gcm.Open(nil, make([]byte, gcm.NonceSize()), ciphertext.Sealed, election[:])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDecryption, err)
+	}
+	return payload, nil
+}
+
+// ThresholdDecrypt opens the ciphertext with the talliers' decryption shares
+// of its C1, or their guardians' for absent talliers.
+func ThresholdDecrypt(ciphertext Ciphertext, contributions []pki.DkgContribution, shares map[int][]pki.DecryptionShare, curve elliptic.Curve) ([]byte, error) {
+	if len(contributions) == 0 {
+		return nil, fmt.Errorf("%w: no contributions", ErrDecryption)
+	}
+	if !ciphertext.C1.IsOnCurve(curve) {
+		return nil, fmt.Errorf("%w: C1 is not on the curve", ErrDecryption)
+	}
+	S, err := pki.CombineDecryptionShares(ciphertext.C1, contributions, shares, curve)
+	if err != nil {
+		return nil, err
+	}
+	return Decrypt(ciphertext, contributions[0].Election, S, curve)
+}
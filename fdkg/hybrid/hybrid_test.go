@@ -0,0 +1,80 @@
+package hybrid
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/delendum-xyz/private-voting/fdkg/common"
+	"github.com/delendum-xyz/private-voting/fdkg/pki"
+	"github.com/samber/lo"
+	"github.com/torusresearch/pvss/secp256k1"
+)
+
+var curve = secp256k1.Curve
+
+func TestThresholdDecrypt(t *testing.T) {
+	pki.Output = io.Discard
+	config := common.VotingConfig{
+		Size:          6,
+		Options:       2,
+		Threshold:     2,
+		GuardiansSize: 3,
+	}
+	r := rand.New(rand.NewSource(0))
+	_, dkgNodes, err := pki.GenerateSetOfNodes(config, 3, pki.UniformSelector{}, curve, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	contributions := lo.Map(dkgNodes, func(p pki.DkgParty, _ int) pki.DkgContribution { return p.Contribution(curve) })
+	key := common.PointZero()
+	for _, c := range contributions {
+		key = common.BigIntToPoint(curve.Add(&key.X, &key.Y, &c.VotingPublicKey.X, &c.VotingPublicKey.Y))
+	}
+
+	bid := bytes.Repeat([]byte("sealed bid of 1000 tokens; "), 10)
+	ciphertext := Encrypt(bid, config.ElectionID(), key, curve)
+
+	// the first tallier is offline and its guardians decrypt for it
+	absent := dkgNodes[0]
+	shares := map[int][]pki.DecryptionShare{}
+	for _, p := range dkgNodes[1:] {
+		shares[p.Index] = []pki.DecryptionShare{p.PartialDecryption(ciphertext.C1, curve)}
+	}
+	for _, share := range absent.GenerateShares(curve) {
+		shares[absent.Index] = append(shares[absent.Index], pki.ProveDecryptionShare(share.To, share.Value, ciphertext.C1, config.ElectionID(), curve))
+	}
+	payload, err := ThresholdDecrypt(ciphertext, contributions, shares, curve)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(payload, bid) {
+		t.Errorf("Expected %q, got %q", bid, payload)
+	}
+
+	tampered := ciphertext
+	tampered.Sealed = append([]byte{}, ciphertext.Sealed...)
+	tampered.Sealed[0] ^= 1
+	if _, err := ThresholdDecrypt(tampered, contributions, shares, curve); !errors.Is(err, ErrDecryption) {
+		t.Errorf("Expected ErrDecryption for a tampered ciphertext, got %v", err)
+	}
+
+	shares[absent.Index] = shares[absent.Index][:1]
+	if _, err := ThresholdDecrypt(ciphertext, contributions, shares, curve); !errors.Is(err, pki.ErrNotEnoughShares) {
+		t.Errorf("Expected ErrNotEnoughShares, got %v", err)
+	}
+}
+
+func TestDecryptInAnotherElection(t *testing.T) {
+	x := common.BigIntToPoint(curve.ScalarBaseMult([]byte{42}))
+	ciphertext := Encrypt([]byte("escrow"), common.Hash{1}, x, curve)
+	S := common.BigIntToPoint(curve.ScalarMult(&ciphertext.C1.X, &ciphertext.C1.Y, []byte{42}))
+	if payload, err := Decrypt(ciphertext, common.Hash{1}, S, curve); err != nil || string(payload) != "escrow" {
+		t.Fatalf("Expected escrow, got %q, %v", payload, err)
+	}
+	if _, err := Decrypt(ciphertext, common.Hash{2}, S, curve); !errors.Is(err, ErrDecryption) {
+		t.Errorf("Expected ErrDecryption in another election, got %v", err)
+	}
+}
@@ -0,0 +1,345 @@
+package keystore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/elliptic"
+	cryptoRand "crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+
+	"github.com/delendum-xyz/private-voting/fdkg/common"
+	"github.com/delendum-xyz/private-voting/fdkg/pki"
+	"github.com/delendum-xyz/private-voting/fdkg/polynomial"
+	"github.com/delendum-xyz/private-voting/fdkg/sss"
+	"github.com/delendum-xyz/private-voting/fdkg/utils"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Version is the current keystore file format.
+const Version = 1
+
+const (
+	kdfScrypt    = "scrypt"
+	cipherAESGCM = "aes-256-gcm"
+	keyLen       = 32
+	saltLen      = 32
+)
+
+var (
+	ErrUnsupportedVersion = errors.New("unsupported keystore version")
+	ErrUnsupportedCipher  = errors.New("unsupported keystore kdf or cipher")
+	ErrDecryptionFailed   = errors.New("wrong passphrase or corrupted keystore")
+)
+
+// ScryptParams are the scrypt cost parameters stored in the file header.
+type ScryptParams struct {
+	N int `json:"n"`
+	R int `json:"r"`
+	P int `json:"p"`
+}
+
+// The largest scrypt cost a keystore may ask for. Decrypt checks the stored
+// parameters against these before deriving the key, as a tampered header is
+// only caught by GCM afterwards: scrypt takes 128*N*r bytes and N*r*p time.
+const (
+	maxScryptN      = 1 << 20
+	maxScryptP      = 4
+	maxScryptMemory = 1 << 30
+)
+
+func (p ScryptParams) validate() error {
+	if p.N < 2 || p.N > maxScryptN || p.N&(p.N-1) != 0 || p.R < 1 || p.P < 1 || p.P > maxScryptP || 128*p.N*p.R > maxScryptMemory {
+		return fmt.Errorf("%w: scrypt N=%d r=%d p=%d", ErrUnsupportedCipher, p.N, p.R, p.P)
+	}
+	return nil
+}
+
+// StandardScrypt is the cost used for keystores written to disk.
+var StandardScrypt = ScryptParams{N: 1 << 18, R: 8, P: 1}
+
+// LightScrypt is a cheap setting for tests and simulations.
+var LightScrypt = ScryptParams{N: 1 << 12, R: 8, P: 1}
+
+// Secrets is everything a node needs to resume its DKG role: its own keys and
+// polynomial, the guardians it dealt to, and the shares it holds as a guardian.
+type Secrets struct {
+	Party          pki.DkgParty
+	ReceivedShares []sss.Share
+}
+
+type header struct {
+	Version   int          `json:"version"`
+	KDF       string       `json:"kdf"`
+	KDFParams ScryptParams `json:"kdfparams"`
+	Salt      []byte       `json:"salt"`
+	Cipher    string       `json:"cipher"`
+	Nonce     []byte       `json:"nonce"`
+}
+
+type file struct {
+	header
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+type pointJSON struct {
+	X string `json:"x"`
+	Y string `json:"y"`
+}
+
+type publicPartyJSON struct {
+	Index           int       `json:"index"`
+	PublicKey       pointJSON `json:"publicKey"`
+	VotingPublicKey pointJSON `json:"votingPublicKey"`
+}
+
+type shareJSON struct {
+	From            int    `json:"from"`
+	To              int    `json:"to"`
+	Value           string `json:"value"`
+	BasisPolynomial string `json:"basisPolynomial"`
+}
+
+type secretsJSON struct {
+	Index              int                 `json:"index"`
+	PrivateKey         string              `json:"privateKey"`
+	VotingPrivKeyShare string              `json:"votingPrivKeyShare"`
+	Coefficients       []string            `json:"coefficients"`
+	GuardiansSize      int                 `json:"guardiansSize"`
+	Vote               int                 `json:"vote"`
+	Config             common.VotingConfig `json:"config"`
+	TrustedParties     []publicPartyJSON   `json:"trustedParties"`
+	ReceivedShares     []shareJSON         `json:"receivedShares"`
+}
+
+func bigToHex(b big.Int) string {
+	return b.Text(16)
+}
+
+func hexToBig(s string) (big.Int, error) {
+	b, ok := new(big.Int).SetString(s, 16)
+	if !ok {
+		return big.Int{}, fmt.Errorf("invalid hex %q in keystore", s)
+	}
+	return *b, nil
+}
+
+func pointToJSON(p common.Point) pointJSON {
+	return pointJSON{X: bigToHex(p.X), Y: bigToHex(p.Y)}
+}
+
+func pointFromJSON(p pointJSON) (common.Point, error) {
+	x, err := hexToBig(p.X)
+	if err != nil {
+		return common.Point{}, err
+	}
+	y, err := hexToBig(p.Y)
+	if err != nil {
+		return common.Point{}, err
+	}
+	return common.BigIntToPoint(&x, &y), nil
+}
+
+func toJSON(s Secrets) secretsJSON {
+	p := s.Party
+	return secretsJSON{
+		Index:              p.Index,
+		PrivateKey:         bigToHex(p.PrivateKey),
+		VotingPrivKeyShare: bigToHex(p.VotingPrivKeyShare),
+		Coefficients:       utils.Map(p.Polynomial.Coefficients(), bigToHex),
+		GuardiansSize:      p.GuardiansSize,
+		Vote:               p.Vote(),
+		Config:             p.Config(),
+		TrustedParties: utils.Map(p.TrustedParties, func(t pki.PublicParty) publicPartyJSON {
+			return publicPartyJSON{Index: t.Index, PublicKey: pointToJSON(t.PublicKey), VotingPublicKey: pointToJSON(t.VotingPublicKey)}
+		}),
+		ReceivedShares: utils.Map(s.ReceivedShares, func(share sss.Share) shareJSON {
+			return shareJSON{From: share.From, To: share.To, Value: bigToHex(share.Value), BasisPolynomial: bigToHex(share.BasisPolynomial)}
+		}),
+	}
+}
+
+func fromJSON(s secretsJSON, curve elliptic.Curve) (Secrets, error) {
+	privateKey, err := hexToBig(s.PrivateKey)
+	if err != nil {
+		return Secrets{}, err
+	}
+	votingPrivKeyShare, err := hexToBig(s.VotingPrivKeyShare)
+	if err != nil {
+		return Secrets{}, err
+	}
+	coefficients := make([]big.Int, len(s.Coefficients))
+	for i, c := range s.Coefficients {
+		if coefficients[i], err = hexToBig(c); err != nil {
+			return Secrets{}, err
+		}
+	}
+	if len(coefficients) == 0 || coefficients[0].Cmp(&votingPrivKeyShare) != 0 {
+		return Secrets{}, errors.New("keystore polynomial does not share the voting private key")
+	}
+	trustedParties := make([]pki.PublicParty, len(s.TrustedParties))
+	for i, t := range s.TrustedParties {
+		publicKey, err := pointFromJSON(t.PublicKey)
+		if err != nil {
+			return Secrets{}, err
+		}
+		votingPublicKey, err := pointFromJSON(t.VotingPublicKey)
+		if err != nil {
+			return Secrets{}, err
+		}
+		trustedParties[i] = pki.PublicParty{Index: t.Index, PublicKey: publicKey, VotingPublicKey: votingPublicKey}
+	}
+	receivedShares := make([]sss.Share, len(s.ReceivedShares))
+	for i, share := range s.ReceivedShares {
+		value, err := hexToBig(share.Value)
+		if err != nil {
+			return Secrets{}, err
+		}
+		basis, err := hexToBig(share.BasisPolynomial)
+		if err != nil {
+			return Secrets{}, err
+		}
+		receivedShares[i] = sss.Share{From: share.From, To: share.To, Value: value, BasisPolynomial: basis}
+	}
+
+	poly := polynomial.NewPolynomial(coefficients, curve)
+	party := pki.RestoreLocalParty(s.Index, privateKey, votingPrivKeyShare, poly, s.GuardiansSize, s.Vote, s.Config, curve)
+	return Secrets{
+		Party:          party.ToDkgParty(trustedParties),
+		ReceivedShares: receivedShares,
+	}, nil
+}
+
+func deriveKey(passphrase []byte, salt []byte, params ScryptParams) ([]byte, error) {
+	return scrypt.Key(passphrase, salt, params.N, params.R, params.P, keyLen)
+}
+
+func aead(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Encrypt serializes the secrets and seals them with AES-256-GCM under a key
+// derived from the passphrase with scrypt. The header is authenticated as
+// associated data, so tampering with the version or cost parameters is caught.
+func Encrypt(secrets Secrets, passphrase []byte, params ScryptParams) ([]byte, error) {
+	plaintext, err := json.Marshal(toJSON(secrets))
+	if err != nil {
+		return nil, err
+	}
+	if err := params.validate(); err != nil {
+		return nil, err
+	}
+	salt := make([]byte, saltLen)
+	if _, err := cryptoRand.Read(salt); err != nil {
+		return nil, err
+	}
+	key, err := deriveKey(passphrase, salt, params)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := aead(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := cryptoRand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	h := header{
+		Version:   Version,
+		KDF:       kdfScrypt,
+		KDFParams: params,
+		Salt:      salt,
+		Cipher:    cipherAESGCM,
+		Nonce:     nonce,
+	}
+	ad, err := json.Marshal(h)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(file{header: h, Ciphertext: gcm.Seal(nil, nonce, plaintext, ad)}, "", "  ")
+}
+
+func Decrypt(data []byte, passphrase []byte, curve elliptic.Curve) (Secrets, error) {
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil {
+		return Secrets{}, err
+	}
+	if f.Version != Version {
+		return Secrets{}, fmt.Errorf("%w: %d", ErrUnsupportedVersion, f.Version)
+	}
+	if f.KDF != kdfScrypt || f.Cipher != cipherAESGCM {
+		return Secrets{}, fmt.Errorf("%w: %s/%s", ErrUnsupportedCipher, f.KDF, f.Cipher)
+	}
+	if err := f.KDFParams.validate(); err != nil {
+		return Secrets{}, err
+	}
+	key, err := deriveKey(passphrase, f.Salt, f.KDFParams)
+	if err != nil {
+		return Secrets{}, err
+	}
+	gcm, err := aead(key)
+	if err != nil {
+		return Secrets{}, err
+	}
+	if len(f.Nonce) != gcm.NonceSize() {
+		return Secrets{}, ErrDecryptionFailed
+	}
+	ad, err := json.Marshal(f.header)
+	if err != nil {
+		return Secrets{}, err
+	}
+	plaintext, err := gcm.Open(nil, f.Nonce, f.Ciphertext, ad)
+	if err != nil {
+		return Secrets{}, ErrDecryptionFailed
+	}
+	var s secretsJSON
+	if err := json.Unmarshal(plaintext, &s); err != nil {
+		return Secrets{}, err
+	}
+	return fromJSON(s, curve)
+}
+
+// Save writes the encrypted keystore to path, readable only by the owner. It
+// writes a temporary file in the same directory, syncs it and renames it over
+// path, so a crash leaves either the old keystore or the new one.
+func Save(path string, secrets Secrets, passphrase []byte, params ScryptParams) error {
+	data, err := Encrypt(secrets, passphrase, params)
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+func Load(path string, passphrase []byte, curve elliptic.Curve) (Secrets, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Secrets{}, err
+	}
+	return Decrypt(data, passphrase, curve)
+}
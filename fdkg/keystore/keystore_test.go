@@ -0,0 +1,154 @@
+package keystore
+
+import (
+	"errors"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/delendum-xyz/private-voting/fdkg/common"
+	"github.com/delendum-xyz/private-voting/fdkg/pki"
+	"github.com/delendum-xyz/private-voting/fdkg/sss"
+	"github.com/torusresearch/pvss/secp256k1"
+)
+
+var curve = secp256k1.Curve
+
+func generateSecrets(seed int64) Secrets {
+	config := common.VotingConfig{
+		Size:          6,
+		Options:       2,
+		Threshold:     2,
+		GuardiansSize: 3,
+	}
+	r := rand.New(rand.NewSource(seed))
+	_, dkgNodes, err := pki.GenerateSetOfNodes(config, 6, pki.UniformSelector{}, curve, r)
+	if err != nil {
+		panic(err)
+	}
+	received := make(map[int][]sss.Share)
+	for _, node := range dkgNodes {
+		for _, share := range node.GenerateShares(curve) {
+			received[share.To] = append(received[share.To], share)
+		}
+	}
+	party := dkgNodes[0]
+	return Secrets{Party: party, ReceivedShares: received[party.Index]}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	secrets := generateSecrets(0)
+	path := filepath.Join(t.TempDir(), "party.json")
+	passphrase := []byte("correct horse battery staple")
+
+	if err := Save(path, secrets, passphrase, LightScrypt); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(path, passphrase, curve)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	party, restored := secrets.Party, loaded.Party
+	if party.Threshold != restored.Threshold || party.GuardiansSize != restored.GuardiansSize {
+		t.Errorf("Restored guardian set (%d, %d), expected (%d, %d)", restored.Threshold, restored.GuardiansSize, party.Threshold, party.GuardiansSize)
+	}
+	if party.Index != restored.Index || party.Vote() != restored.Vote() || party.Config() != restored.Config() {
+		t.Errorf("Restored party metadata differs: %v vs %v", party.PublicParty, restored.PublicParty)
+	}
+	if party.PrivateKey.Cmp(&restored.PrivateKey) != 0 || !party.PublicKey.Equal(restored.PublicKey) {
+		t.Errorf("Restored private key differs")
+	}
+	if party.VotingPrivKeyShare.Cmp(&restored.VotingPrivKeyShare) != 0 || !party.VotingPublicKey.Equal(restored.VotingPublicKey) {
+		t.Errorf("Restored voting key share differs")
+	}
+	if len(party.TrustedParties) != len(restored.TrustedParties) {
+		t.Fatalf("Expected %d trusted parties, got %d", len(party.TrustedParties), len(restored.TrustedParties))
+	}
+
+	// the restored party deals exactly the same shares
+	shares, restoredShares := party.GenerateShares(curve), restored.GenerateShares(curve)
+	for i := range shares {
+		if shares[i].To != restoredShares[i].To || shares[i].Value.Cmp(&restoredShares[i].Value) != 0 {
+			t.Errorf("Share %d differs after restore: %v vs %v", i, shares[i], restoredShares[i])
+		}
+	}
+
+	if len(secrets.ReceivedShares) != len(loaded.ReceivedShares) {
+		t.Fatalf("Expected %d received shares, got %d", len(secrets.ReceivedShares), len(loaded.ReceivedShares))
+	}
+	for i, share := range secrets.ReceivedShares {
+		got := loaded.ReceivedShares[i]
+		if share.From != got.From || share.To != got.To || share.Value.Cmp(&got.Value) != 0 || share.BasisPolynomial.Cmp(&got.BasisPolynomial) != 0 {
+			t.Errorf("Received share %d differs after restore: %v vs %v", i, share, got)
+		}
+	}
+}
+
+func TestSaveReplaces(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "party.json")
+	passphrase := []byte("correct horse battery staple")
+	for seed := int64(0); seed < 2; seed++ {
+		if err := Save(path, generateSecrets(seed), passphrase, LightScrypt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	loaded, err := Load(path, passphrase, curve)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := generateSecrets(1).Party.PrivateKey; loaded.Party.PrivateKey.Cmp(&expected) != 0 {
+		t.Errorf("Expected the keystore saved last")
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Expected only the keystore in the directory, got %d entries", len(entries))
+	}
+}
+
+func TestWrongPassphrase(t *testing.T) {
+	secrets := generateSecrets(1)
+	data, err := Encrypt(secrets, []byte("secret"), LightScrypt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Decrypt(data, []byte("not the secret"), curve); !errors.Is(err, ErrDecryptionFailed) {
+		t.Errorf("Expected ErrDecryptionFailed, got %v", err)
+	}
+}
+
+func TestTamperedHeader(t *testing.T) {
+	secrets := generateSecrets(2)
+	data, err := Encrypt(secrets, []byte("secret"), LightScrypt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	downgraded := strings.Replace(string(data), `"p": 1`, `"p": 2`, 1)
+	if _, err := Decrypt([]byte(downgraded), []byte("secret"), curve); !errors.Is(err, ErrDecryptionFailed) {
+		t.Errorf("Expected ErrDecryptionFailed for tampered kdf params, got %v", err)
+	}
+
+	// a cost that would exhaust memory is refused before running scrypt
+	for _, cost := range []string{`"n": 1073741824`, `"n": 4097`, `"n": 0`} {
+		expensive := strings.Replace(string(data), `"n": 4096`, cost, 1)
+		if _, err := Decrypt([]byte(expensive), []byte("secret"), curve); !errors.Is(err, ErrUnsupportedCipher) {
+			t.Errorf("Expected ErrUnsupportedCipher for %s, got %v", cost, err)
+		}
+	}
+	huge := strings.Replace(string(data), `"r": 8`, `"r": 100000`, 1)
+	if _, err := Decrypt([]byte(huge), []byte("secret"), curve); !errors.Is(err, ErrUnsupportedCipher) {
+		t.Errorf("Expected ErrUnsupportedCipher for a huge r, got %v", err)
+	}
+
+	future := strings.Replace(string(data), `"version": 1`, `"version": 2`, 1)
+	if _, err := Decrypt([]byte(future), []byte("secret"), curve); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("Expected ErrUnsupportedVersion, got %v", err)
+	}
+}
@@ -0,0 +1,93 @@
+package mixnet
+
+import (
+	"crypto/elliptic"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/delendum-xyz/private-voting/fdkg/common"
+	"github.com/delendum-xyz/private-voting/fdkg/pki"
+	"github.com/delendum-xyz/private-voting/fdkg/schnorr"
+	"github.com/delendum-xyz/private-voting/fdkg/utils"
+)
+
+// MaxMessageSize is the longest message that fits in one point: the x
+// coordinate holds a marker byte, the message and one byte of padding.
+const MaxMessageSize = 30
+
+var (
+	ErrMessageTooLong = errors.New("message too long")
+	ErrNoEncoding     = errors.New("message has no encoding on the curve")
+)
+
+// EncodeMessage maps a message, e.g. a write-in name, to a point whose x
+// coordinate is 0x01 || message || k for the first k that lands on
+// y^2 = x^3 + 7. The marker keeps leading zero bytes of the message.
+func EncodeMessage(message []byte, curve elliptic.Curve) (common.Point, error) {
+	if len(message) > MaxMessageSize {
+		return common.Point{}, fmt.Errorf("%w: %d bytes, at most %d", ErrMessageTooLong, len(message), MaxMessageSize)
+	}
+	P := curve.Params().P
+	for k := 0; k < 256; k++ {
+		x := new(big.Int).SetBytes(append(append([]byte{1}, message...), byte(k)))
+		beta := new(big.Int).Exp(x, big.NewInt(3), P)
+		beta.Add(beta, big.NewInt(7))
+		y := new(big.Int).Exp(beta.Mod(beta, P), utils.SqRoot, P)
+		if curve.IsOnCurve(x, y) {
+			return common.BigIntToPoint(x, y), nil
+		}
+	}
+	return common.Point{}, ErrNoEncoding
+}
+
+// DecodeMessage reverses EncodeMessage. Only a point EncodeMessage returns
+// for the message decodes, so a garbage decryption is not taken for a
+// write-in.
+func DecodeMessage(m common.Point, curve elliptic.Curve) ([]byte, error) {
+	b := m.X.Bytes()
+	if len(b) < 2 || b[0] != 1 {
+		return nil, fmt.Errorf("%w: point encodes no message", ErrNoEncoding)
+	}
+	message := b[1 : len(b)-1]
+	if encoded, err := EncodeMessage(message, curve); err != nil || !encoded.Equal(m) {
+		return nil, fmt.Errorf("%w: point encodes no message", ErrNoEncoding)
+	}
+	return message, nil
+}
+
+// EncryptMessage encrypts the encoded message under encryptionKey. The
+// signature, made with the randomness k of C1 = k*G, proves the voter knows k
+// and binds the ballot to the election, so a ballot cannot be copied and
+// mixed as someone else's. Both k and the signature's nonce come from
+// crypto/rand: k decrypts the ballot and the nonce would reveal k.
+func EncryptMessage(message []byte, election common.Hash, encryptionKey common.Point, curve elliptic.Curve) (common.EncryptedBallot, schnorr.Signature, error) {
+	m, err := EncodeMessage(message, curve)
+	if err != nil {
+		return common.EncryptedBallot{}, schnorr.Signature{}, err
+	}
+	g := group{curve}
+	k := utils.RandomBigIntCrypto(curve)
+	ballot := common.EncryptedBallot{C1: g.base(&k), C2: g.add(g.mul(&k, encryptionKey), m)}
+	return ballot, schnorr.Sign(k, ballotMessage(election, ballot, curve), curve), nil
+}
+
+func ballotMessage(election common.Hash, ballot common.EncryptedBallot, curve elliptic.Curve) []byte {
+	return utils.Keccak256([]byte("fdkg/mixnet/ballot"), election[:], ballot.C2.Marshal(curve))
+}
+
+// VerifyBallot checks the proof of knowledge of a ballot before it is mixed.
+func VerifyBallot(ballot common.EncryptedBallot, signature schnorr.Signature, election common.Hash, curve elliptic.Curve) bool {
+	return ballot.C1.IsOnCurve(curve) && ballot.C2.IsOnCurve(curve) &&
+		schnorr.Verify(ballot.C1, ballotMessage(election, ballot, curve), signature, curve)
+}
+
+// DecryptBallot decrypts one mixed ballot with the talliers' decryption
+// shares of its C1, combined by pki.CombineDecryptionShares.
+func DecryptBallot(ballot common.EncryptedBallot, contributions []pki.DkgContribution, shares map[int][]pki.DecryptionShare, curve elliptic.Curve) (common.Point, error) {
+	Z, err := pki.CombineDecryptionShares(ballot.C1, contributions, shares, curve)
+	if err != nil {
+		return common.Point{}, err
+	}
+	return group{curve}.sub(ballot.C2, Z), nil
+}
@@ -0,0 +1,204 @@
+package mixnet
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/delendum-xyz/private-voting/fdkg/common"
+	"github.com/delendum-xyz/private-voting/fdkg/elgamal"
+	"github.com/delendum-xyz/private-voting/fdkg/pki"
+	"github.com/delendum-xyz/private-voting/fdkg/utils"
+	"github.com/samber/lo"
+	"github.com/torusresearch/pvss/secp256k1"
+)
+
+var curve = secp256k1.Curve
+
+func TestShuffleProof(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	election := common.Hash{1}
+	key := group{curve}.base(lo.ToPtr(random(curve, r)))
+	var input []common.EncryptedBallot
+	for _, name := range []string{"alice", "bob", "carol", "dave"} {
+		ballot, _, err := EncryptMessage([]byte(name), election, key, curve)
+		if err != nil {
+			t.Fatal(err)
+		}
+		input = append(input, ballot)
+	}
+
+	output, proof := Shuffle(input, election, key, curve, r)
+	if !proof.Verify(input, output, election, key, curve) {
+		t.Fatalf("Valid shuffle rejected")
+	}
+	if proof.Verify(input, output, common.Hash{2}, key, curve) {
+		t.Errorf("Shuffle accepted in another election")
+	}
+	swapped := append([]common.EncryptedBallot{}, output...)
+	swapped[0].C2, swapped[1].C2 = swapped[1].C2, swapped[0].C2
+	if proof.Verify(input, swapped, election, key, curve) {
+		t.Errorf("Shuffle accepted with swapped ciphertext halves")
+	}
+	replaced := append([]common.EncryptedBallot{}, output...)
+	replaced[2], _, _ = EncryptMessage([]byte("mallory"), election, key, curve)
+	if proof.Verify(input, replaced, election, key, curve) {
+		t.Errorf("Shuffle accepted with a replaced ballot")
+	}
+}
+
+func TestMixAndDecrypt(t *testing.T) {
+	pki.Output = io.Discard
+	config := common.VotingConfig{
+		Size:          6,
+		Options:       2,
+		Threshold:     2,
+		GuardiansSize: 3,
+	}
+	r := rand.New(rand.NewSource(0))
+	_, dkgNodes, err := pki.GenerateSetOfNodes(config, 3, pki.UniformSelector{}, curve, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	contributions := lo.Map(dkgNodes, func(p pki.DkgParty, _ int) pki.DkgContribution { return p.Contribution(curve) })
+	g := group{curve}
+	key := common.PointZero()
+	for _, c := range contributions {
+		key = g.add(key, c.VotingPublicKey)
+	}
+	election := config.ElectionID()
+
+	writeIns := []string{"alice", "bob", "alice", "", "a name of exactly thirty bytes"}
+	var ballots []common.EncryptedBallot
+	for _, name := range writeIns {
+		ballot, signature, err := EncryptMessage([]byte(name), election, key, curve)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !VerifyBallot(ballot, signature, election, curve) {
+			t.Fatalf("Valid ballot %q rejected", name)
+		}
+		ballots = append(ballots, ballot)
+	}
+	if _, _, err := EncryptMessage(make([]byte, MaxMessageSize+1), election, key, curve); !errors.Is(err, ErrMessageTooLong) {
+		t.Errorf("Expected ErrMessageTooLong, got %v", err)
+	}
+
+	stages := Mix(ballots, []int{1, 2, 3}, election, key, curve, r)
+	mixed, err := VerifyMix(ballots, stages, election, key, curve)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the first tallier is offline and its guardians decrypt for it
+	absent := dkgNodes[0]
+	var decrypted []string
+	for _, ballot := range mixed {
+		shares := map[int][]pki.DecryptionShare{}
+		for _, p := range dkgNodes[1:] {
+			shares[p.Index] = []pki.DecryptionShare{p.PartialDecryption(ballot.C1, curve)}
+		}
+		for _, share := range absent.GenerateShares(curve) {
+			shares[absent.Index] = append(shares[absent.Index], pki.ProveDecryptionShare(share.To, share.Value, ballot.C1, election, curve))
+		}
+		m, err := DecryptBallot(ballot, contributions, shares, curve)
+		if err != nil {
+			t.Fatal(err)
+		}
+		message, err := DecodeMessage(m, curve)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decrypted = append(decrypted, string(message))
+	}
+	sort.Strings(writeIns)
+	sort.Strings(decrypted)
+	if !reflect.DeepEqual(writeIns, decrypted) {
+		t.Errorf("Expected write-ins %q, got %q", writeIns, decrypted)
+	}
+
+	stages[1].Output[0], stages[1].Output[1] = stages[1].Output[1], stages[1].Output[0]
+	if _, err := VerifyMix(ballots, stages, election, key, curve); !errors.Is(err, ErrInvalidShuffle) || err.Error() != "invalid shuffle: mix server 2" {
+		t.Errorf("Expected the shuffle of server 2 to be rejected, got %v", err)
+	}
+}
+
+func TestInstantRunoffThroughMix(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	election := common.Hash{1}
+	privKey := random(curve, r)
+	key := group{curve}.base(&privKey)
+
+	// option 2 leads the first round but 1 wins once 0 is eliminated
+	rankings := [][]int{{2, 0, 1}, {2, 1, 0}, {1, 0, 2}, {1, 2, 0}, {0, 1, 2}}
+	packed := lo.Map(rankings, func(ranking []int, _ int) common.EncryptedBallot {
+		ballot := elgamal.EncryptRanking(ranking, election, key, curve, r)
+		if !ballot.Verify(3, election, key, curve) {
+			t.Fatalf("Valid ranked ballot %v rejected", ranking)
+		}
+		return ballot.PackedRanking(curve)
+	})
+	mixed, err := VerifyMix(packed, Mix(packed, []int{1, 2}, election, key, curve, r), election, key, curve)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decrypted := lo.Map(mixed, func(ballot common.EncryptedBallot, _ int) []int {
+		Z := group{curve}.mul(&privKey, ballot.C1)
+		ranking, err := elgamal.DecodeRanking(group{curve}.sub(ballot.C2, Z), 3, curve)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ranking
+	})
+	sortRankings := func(rankings [][]int) {
+		sort.Slice(rankings, func(i, j int) bool { return fmt.Sprint(rankings[i]) < fmt.Sprint(rankings[j]) })
+	}
+	winner, rounds := elgamal.InstantRunoff(decrypted, 3)
+	sortRankings(decrypted)
+	sortRankings(rankings)
+	if !reflect.DeepEqual(decrypted, rankings) {
+		t.Errorf("Expected rankings %v, got %v", rankings, decrypted)
+	}
+	if winner != 1 || !reflect.DeepEqual(rounds, [][]int{{1, 2, 2}, {0, 3, 2}}) {
+		t.Errorf("Expected option 1 to win in rounds [[1 2 2] [0 3 2]], got %d in %v", winner, rounds)
+	}
+}
+
+func TestDecodeMessage(t *testing.T) {
+	m, err := EncodeMessage([]byte("write-in"), curve)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if message, err := DecodeMessage(m, curve); err != nil || string(message) != "write-in" {
+		t.Errorf("Expected \"write-in\", got %q, %v", message, err)
+	}
+
+	// points whose x starts with 1 but which EncodeMessage does not return:
+	// the negated point and one with a later padding byte
+	P := curve.Params().P
+	garbage := []common.Point{common.BigIntToPoint(&m.X, new(big.Int).Sub(P, &m.Y))}
+	x := m.X.Bytes()
+	for k := int(x[len(x)-1]) + 1; k < 256 && len(garbage) == 1; k++ {
+		x[len(x)-1] = byte(k)
+		other := new(big.Int).SetBytes(x)
+		beta := new(big.Int).Exp(other, big.NewInt(3), P)
+		beta.Add(beta, big.NewInt(7))
+		y := new(big.Int).Exp(beta.Mod(beta, P), utils.SqRoot, P)
+		if curve.IsOnCurve(other, y) {
+			garbage = append(garbage, common.BigIntToPoint(other, y))
+		}
+	}
+	if len(garbage) != 2 {
+		t.Fatalf("Found no point with a later padding byte")
+	}
+	for i, p := range garbage {
+		if message, err := DecodeMessage(p, curve); !errors.Is(err, ErrNoEncoding) {
+			t.Errorf("Garbage point %d decoded to %q", i, message)
+		}
+	}
+}
@@ -0,0 +1,298 @@
+// Package mixnet is a verifiable re-encryption mix-net for ballots that cannot
+// be tallied homomorphically, such as write-ins or full rankings. Each mix
+// server re-encrypts and permutes the ballots under the FDKG voting key and
+// proves the shuffle; the output ballots are then decrypted one by one with
+// the talliers' proven partial decryptions.
+//
+// The proof of shuffle is the one of Wikström and Terelius, following the
+// pseudo-code of Haenni et al., "Pseudo-Code Algorithms for Verifiable
+// Re-Encryption Mix-Nets" (FC 2017), written additively.
+package mixnet
+
+import (
+	"crypto/elliptic"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"math/rand"
+
+	"github.com/delendum-xyz/private-voting/fdkg/common"
+	"github.com/delendum-xyz/private-voting/fdkg/utils"
+	"github.com/samber/lo"
+)
+
+var ErrInvalidShuffle = errors.New("invalid shuffle")
+
+// ShuffleProof proves that the output ballots are a re-encryption of a
+// permutation of the input ballots, without revealing the permutation.
+// Commitments commits to the permutation and ChainCommitments to the
+// permuted challenges; C is the Fiat-Shamir challenge and the S fields the
+// responses.
+type ShuffleProof struct {
+	Commitments      []common.Point
+	ChainCommitments []common.Point
+	C                big.Int
+	S1, S2, S3, S4   big.Int
+	SHat, SPrime     []big.Int
+}
+
+// generators are h and h_1..h_n, hashed to the curve so that nobody knows
+// their discrete logs.
+func generators(n int, curve elliptic.Curve) (common.Point, []common.Point) {
+	h := *utils.HashToPoint([]byte("fdkg/mixnet/h"), curve)
+	hs := lo.Map(lo.Range(n), func(i int, _ int) common.Point {
+		index := make([]byte, 8)
+		binary.BigEndian.PutUint64(index, uint64(i))
+		return *utils.HashToPoint(append([]byte("fdkg/mixnet/h_i"), index...), curve)
+	})
+	return h, hs
+}
+
+type group struct {
+	curve elliptic.Curve
+}
+
+func (g group) add(p, q common.Point) common.Point {
+	return common.BigIntToPoint(g.curve.Add(&p.X, &p.Y, &q.X, &q.Y))
+}
+
+func (g group) sub(p, q common.Point) common.Point {
+	if q.X.Sign() == 0 && q.Y.Sign() == 0 {
+		return p
+	}
+	y := new(big.Int).Neg(&q.Y)
+	return g.add(p, common.BigIntToPoint(&q.X, y.Mod(y, g.curve.Params().P)))
+}
+
+func (g group) mul(k *big.Int, p common.Point) common.Point {
+	s := new(big.Int).Mod(k, g.curve.Params().N)
+	return common.BigIntToPoint(g.curve.ScalarMult(&p.X, &p.Y, s.Bytes()))
+}
+
+func (g group) base(k *big.Int) common.Point {
+	s := new(big.Int).Mod(k, g.curve.Params().N)
+	return common.BigIntToPoint(g.curve.ScalarBaseMult(s.Bytes()))
+}
+
+// sum computes sum_i k_i * P_i.
+func (g group) sum(ks []big.Int, ps []common.Point) common.Point {
+	return utils.MultiScalarMult(ks, ps, g.curve)
+}
+
+func (g group) scalar(data ...[]byte) big.Int {
+	c := new(big.Int).SetBytes(utils.Keccak256(data...))
+	return *c.Mod(c, g.curve.Params().N)
+}
+
+func (g group) marshal(points ...common.Point) [][]byte {
+	return lo.Map(points, func(p common.Point, _ int) []byte { return p.Marshal(g.curve) })
+}
+
+func (g group) marshalBallots(ballots []common.EncryptedBallot) [][]byte {
+	return lo.FlatMap(ballots, func(b common.EncryptedBallot, _ int) [][]byte { return g.marshal(b.C1, b.C2) })
+}
+
+// challenges are u_i = H(statement, i) for every input ballot.
+func (g group) challenges(statement [][]byte, n int) []big.Int {
+	seed := utils.Keccak256(statement...)
+	return lo.Map(lo.Range(n), func(i int, _ int) big.Int {
+		index := make([]byte, 8)
+		binary.BigEndian.PutUint64(index, uint64(i))
+		return g.scalar([]byte("fdkg/mixnet/u"), seed, index)
+	})
+}
+
+func (g group) statement(election common.Hash, encryptionKey common.Point, input, output []common.EncryptedBallot, commitments []common.Point) [][]byte {
+	data := [][]byte{[]byte("fdkg/mixnet"), election[:]}
+	data = append(data, g.marshal(encryptionKey)...)
+	data = append(data, g.marshalBallots(input)...)
+	data = append(data, g.marshalBallots(output)...)
+	return append(data, g.marshal(commitments...)...)
+}
+
+func random(curve elliptic.Curve, r *rand.Rand) big.Int {
+	return utils.RandomBigInt(curve, r)
+}
+
+// Shuffle re-encrypts the ballots under encryptionKey in a random order and
+// proves it.
+func Shuffle(input []common.EncryptedBallot, election common.Hash, encryptionKey common.Point, curve elliptic.Curve, r *rand.Rand) ([]common.EncryptedBallot, ShuffleProof) {
+	n := len(input)
+	g := group{curve}
+	N := curve.Params().N
+	h, hs := generators(n, curve)
+
+	// output i is input pi[i] re-encrypted with rho[i]
+	pi := r.Perm(n)
+	rho := lo.Map(lo.Range(n), func(int, int) big.Int { return random(curve, r) })
+	output := lo.Map(pi, func(j int, i int) common.EncryptedBallot {
+		return common.EncryptedBallot{
+			C1: g.add(input[j].C1, g.base(&rho[i])),
+			C2: g.add(input[j].C2, g.mul(&rho[i], encryptionKey)),
+		}
+	})
+
+	// permutation commitment c_pi(i) = r_pi(i)*G + h_i
+	rs := make([]big.Int, n)
+	commitments := make([]common.Point, n)
+	for i, j := range pi {
+		rs[j] = random(curve, r)
+		commitments[j] = g.add(g.base(&rs[j]), hs[i])
+	}
+
+	stmt := g.statement(election, encryptionKey, input, output, commitments)
+	u := g.challenges(stmt, n)
+	uPrime := lo.Map(pi, func(j int, _ int) big.Int { return u[j] })
+
+	// commitment chain chat_i = R_i*G + u'_i*chat_(i-1), chat_0 = h
+	R := make([]big.Int, n)
+	chain := make([]common.Point, n)
+	previous := h
+	for i := range chain {
+		R[i] = random(curve, r)
+		chain[i] = g.add(g.base(&R[i]), g.mul(&uPrime[i], previous))
+		previous = chain[i]
+	}
+
+	w1, w2, w3, w4 := random(curve, r), random(curve, r), random(curve, r), random(curve, r)
+	wHat := lo.Map(lo.Range(n), func(int, int) big.Int { return random(curve, r) })
+	wPrime := lo.Map(lo.Range(n), func(int, int) big.Int { return random(curve, r) })
+	t := commitmentsFor(g, encryptionKey, output, hs, h, chain, &w1, &w2, &w3, &w4, wHat, wPrime)
+
+	proof := ShuffleProof{Commitments: commitments, ChainCommitments: chain}
+	proof.C = g.scalar(append(append(stmt, g.marshal(chain...)...), g.marshal(t...)...)...)
+	c := &proof.C
+	response := func(w *big.Int, secret *big.Int) big.Int {
+		s := new(big.Int).Mul(c, secret)
+		s.Add(s, w)
+		return *s.Mod(s, N)
+	}
+
+	rBar := new(big.Int)
+	rTilde := new(big.Int)
+	for j := range rs {
+		rBar.Add(rBar, &rs[j])
+		rTilde.Add(rTilde, new(big.Int).Mul(&rs[j], &u[j]))
+	}
+	// rhat = sum_i R_i * v_i with v_i = prod_(k>i) u'_k
+	rHat := new(big.Int)
+	v := big.NewInt(1)
+	for i := n - 1; i >= 0; i-- {
+		rHat.Add(rHat, new(big.Int).Mul(&R[i], v))
+		v = new(big.Int).Mod(new(big.Int).Mul(v, &uPrime[i]), N)
+	}
+	rhoBar := new(big.Int)
+	for i := range rho {
+		rhoBar.Add(rhoBar, new(big.Int).Mul(&rho[i], &uPrime[i]))
+	}
+	proof.S1 = response(&w1, rBar.Mod(rBar, N))
+	proof.S2 = response(&w2, rHat.Mod(rHat, N))
+	proof.S3 = response(&w3, rTilde.Mod(rTilde, N))
+	proof.S4 = response(&w4, rhoBar.Mod(rhoBar, N))
+	proof.SHat = lo.Map(R, func(Ri big.Int, i int) big.Int { return response(&wHat[i], &Ri) })
+	proof.SPrime = lo.Map(uPrime, func(ui big.Int, i int) big.Int { return response(&wPrime[i], &ui) })
+	return output, proof
+}
+
+// commitmentsFor computes the prover's commitments t1..t4 and that_i; the
+// verifier gets the same points from the responses.
+func commitmentsFor(g group, encryptionKey common.Point, output []common.EncryptedBallot, hs []common.Point, h common.Point, chain []common.Point, w1, w2, w3, w4 *big.Int, wHat, wPrime []big.Int) []common.Point {
+	t := []common.Point{
+		g.base(w1),
+		g.base(w2),
+		g.add(g.base(w3), g.sum(wPrime, hs)),
+		g.sub(g.sum(wPrime, lo.Map(output, func(b common.EncryptedBallot, _ int) common.Point { return b.C2 })), g.mul(w4, encryptionKey)),
+		g.sub(g.sum(wPrime, lo.Map(output, func(b common.EncryptedBallot, _ int) common.Point { return b.C1 })), g.base(w4)),
+	}
+	previous := h
+	for i := range chain {
+		t = append(t, g.add(g.base(&wHat[i]), g.mul(&wPrime[i], previous)))
+		previous = chain[i]
+	}
+	return t
+}
+
+// Verify checks the proof that output is a shuffle of input.
+func (p ShuffleProof) Verify(input, output []common.EncryptedBallot, election common.Hash, encryptionKey common.Point, curve elliptic.Curve) bool {
+	n := len(input)
+	if n == 0 {
+		return len(output) == 0
+	}
+	if len(output) != n || len(p.Commitments) != n || len(p.ChainCommitments) != n || len(p.SHat) != n || len(p.SPrime) != n {
+		return false
+	}
+	points := append(append([]common.Point{}, p.Commitments...), p.ChainCommitments...)
+	for _, b := range append(append([]common.EncryptedBallot{}, input...), output...) {
+		points = append(points, b.C1, b.C2)
+	}
+	if !lo.EveryBy(points, func(q common.Point) bool { return q.IsOnCurve(curve) }) {
+		return false
+	}
+	g := group{curve}
+	N := curve.Params().N
+	h, hs := generators(n, curve)
+
+	stmt := g.statement(election, encryptionKey, input, output, p.Commitments)
+	u := g.challenges(stmt, n)
+	uProduct := big.NewInt(1)
+	for i := range u {
+		uProduct.Mod(uProduct.Mul(uProduct, &u[i]), N)
+	}
+
+	// t = s*X - c*Y, where Y is the statement the commitment opens to
+	negC := new(big.Int).Sub(N, &p.C)
+	cBar := g.sub(lo.Reduce(p.Commitments, func(sum common.Point, c common.Point, _ int) common.Point { return g.add(sum, c) }, common.PointZero()),
+		lo.Reduce(hs, func(sum common.Point, hi common.Point, _ int) common.Point { return g.add(sum, hi) }, common.PointZero()))
+	cHat := g.sub(p.ChainCommitments[n-1], g.mul(uProduct, h))
+	cTilde := g.sum(u, p.Commitments)
+	eC2 := g.sum(u, lo.Map(input, func(b common.EncryptedBallot, _ int) common.Point { return b.C2 }))
+	eC1 := g.sum(u, lo.Map(input, func(b common.EncryptedBallot, _ int) common.Point { return b.C1 }))
+
+	negS4 := new(big.Int).Sub(N, &p.S4)
+	t := []common.Point{
+		g.add(g.mul(negC, cBar), g.base(&p.S1)),
+		g.add(g.mul(negC, cHat), g.base(&p.S2)),
+		g.add(g.add(g.mul(negC, cTilde), g.base(&p.S3)), g.sum(p.SPrime, hs)),
+		g.add(g.add(g.mul(negC, eC2), g.mul(negS4, encryptionKey)), g.sum(p.SPrime, lo.Map(output, func(b common.EncryptedBallot, _ int) common.Point { return b.C2 }))),
+		g.add(g.add(g.mul(negC, eC1), g.base(negS4)), g.sum(p.SPrime, lo.Map(output, func(b common.EncryptedBallot, _ int) common.Point { return b.C1 }))),
+	}
+	previous := h
+	for i, chain := range p.ChainCommitments {
+		t = append(t, g.add(g.add(g.mul(negC, chain), g.base(&p.SHat[i])), g.mul(&p.SPrime[i], previous)))
+		previous = chain
+	}
+	c := g.scalar(append(append(stmt, g.marshal(p.ChainCommitments...)...), g.marshal(t...)...)...)
+	return c.Cmp(&p.C) == 0
+}
+
+// Stage is the output of one mix server and its proof.
+type Stage struct {
+	Server int
+	Output []common.EncryptedBallot
+	Proof  ShuffleProof
+}
+
+// Mix runs the ballots through the mix servers in turn. The result is
+// anonymous as long as one of them keeps its permutation secret.
+func Mix(ballots []common.EncryptedBallot, servers []int, election common.Hash, encryptionKey common.Point, curve elliptic.Curve, r *rand.Rand) []Stage {
+	stages := make([]Stage, len(servers))
+	for i, server := range servers {
+		output, proof := Shuffle(ballots, election, encryptionKey, curve, r)
+		stages[i] = Stage{Server: server, Output: output, Proof: proof}
+		ballots = output
+	}
+	return stages
+}
+
+// VerifyMix checks every stage against the output of the previous one and
+// returns the ballots of the last stage.
+func VerifyMix(ballots []common.EncryptedBallot, stages []Stage, election common.Hash, encryptionKey common.Point, curve elliptic.Curve) ([]common.EncryptedBallot, error) {
+	for _, stage := range stages {
+		if !stage.Proof.Verify(ballots, stage.Output, election, encryptionKey, curve) {
+			return nil, fmt.Errorf("%w: mix server %d", ErrInvalidShuffle, stage.Server)
+		}
+		ballots = stage.Output
+	}
+	return ballots, nil
+}
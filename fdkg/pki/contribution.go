@@ -0,0 +1,122 @@
+package pki
+
+import (
+	"crypto/elliptic"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/delendum-xyz/private-voting/fdkg/common"
+	"github.com/delendum-xyz/private-voting/fdkg/sss"
+	"github.com/delendum-xyz/private-voting/fdkg/utils"
+	"github.com/samber/lo"
+)
+
+var (
+	ErrInvalidContribution = errors.New("invalid DKG contribution")
+	ErrNotEnoughShares     = errors.New("not enough shares to reach the party's threshold")
+)
+
+// DkgContribution is what a tallier publishes in the distribution phase: its
+// share of the voting key, its own threshold t_i, its k_i guardians and the
+// Feldman commitments a_j*G to the coefficients of its polynomial. Election
+// binds the commitments, and so the shares verified against them, to one
+// election.
+type DkgContribution struct {
+	Election        common.Hash
+	Index           int
+	VotingPublicKey common.Point
+	Threshold       int
+	Guardians       []int
+	Commitments     []common.Point
+}
+
+func (p DkgParty) Contribution(curve elliptic.Curve) DkgContribution {
+	return DkgContribution{
+		Election:        p.config.ElectionID(),
+		Index:           p.Index,
+		VotingPublicKey: p.VotingPublicKey,
+		Threshold:       p.Threshold,
+		Guardians:       lo.Map(p.TrustedParties, func(party PublicParty, _ int) int { return party.Index }),
+		Commitments: lo.Map(p.Polynomial.Coefficients(), func(coeff big.Int, _ int) common.Point {
+			return common.BigIntToPoint(curve.ScalarBaseMult(coeff.Bytes()))
+		}),
+	}
+}
+
+// Validate checks the contribution is well formed and its (t_i, k_i) lies
+// within the election bounds.
+func (c DkgContribution) Validate(config common.VotingConfig) error {
+	if c.Election != config.ElectionID() {
+		return fmt.Errorf("party %d: %w: contribution for election %v", c.Index, common.ErrWrongElection, c.Election)
+	}
+	if err := c.validateSets(config); err != nil {
+		return err
+	}
+	if len(c.Commitments) != c.Threshold {
+		return fmt.Errorf("party %d: %w: %d commitments for threshold %d", c.Index, ErrInvalidContribution, len(c.Commitments), c.Threshold)
+	}
+	if !c.Commitments[0].Equal(c.VotingPublicKey) {
+		return fmt.Errorf("party %d: %w: first commitment is not the voting public key", c.Index, ErrInvalidContribution)
+	}
+	return nil
+}
+
+// validateSets checks the threshold and guardian set, without the commitments.
+func (c DkgContribution) validateSets(config common.VotingConfig) error {
+	if err := config.ValidateGuardianSet(c.Threshold, len(c.Guardians)); err != nil {
+		return fmt.Errorf("party %d: %w", c.Index, err)
+	}
+	if len(lo.Uniq(c.Guardians)) != len(c.Guardians) || lo.Contains(c.Guardians, c.Index) || lo.Contains(c.Guardians, 0) {
+		return fmt.Errorf("party %d: %w: invalid guardian set %v", c.Index, ErrInvalidContribution, c.Guardians)
+	}
+	return nil
+}
+
+// ExpectedShare evaluates the committed polynomial in the exponent,
+// f(x)*G = sum_j C_j * x^j.
+func (c DkgContribution) ExpectedShare(x int, curve elliptic.Curve) common.Point {
+	powers := make([]big.Int, len(c.Commitments))
+	for j := range powers {
+		powers[j].Exp(big.NewInt(int64(x)), big.NewInt(int64(j)), curve.Params().N)
+	}
+	return utils.MultiScalarMult(powers, c.Commitments, curve)
+}
+
+// VerifyShare checks a share dealt by this party against its commitments.
+func (c DkgContribution) VerifyShare(share sss.Share, curve elliptic.Curve) bool {
+	if share.From != c.Index || !lo.Contains(c.Guardians, share.To) {
+		return false
+	}
+	expected := c.ExpectedShare(share.To, curve)
+	X, Y := curve.ScalarBaseMult(share.Value.Bytes())
+	return expected.X.Cmp(X) == 0 && expected.Y.Cmp(Y) == 0
+}
+
+// ReconstructSecret recovers the party's VotingPrivKeyShare from the shares of
+// any t_i of its guardians.
+func (c DkgContribution) ReconstructSecret(shares []sss.Share, curve elliptic.Curve) (big.Int, error) {
+	shares = lo.UniqBy(lo.Filter(shares, func(s sss.Share, _ int) bool { return s.From == c.Index }), func(s sss.Share) int { return s.To })
+	if len(shares) < c.Threshold {
+		return big.Int{}, fmt.Errorf("party %d: %w: %d of %d", c.Index, ErrNotEnoughShares, len(shares), c.Threshold)
+	}
+	primaryShares := lo.Map(shares[:c.Threshold], func(s sss.Share, _ int) common.PrimaryShare { return s.ToPrimaryShare() })
+	return *sss.LagrangeScalar(primaryShares, 0, curve), nil
+}
+
+// ReconstructPartialDecryption recovers the party's partial decryption
+// d_i*C1 from the partial decryptions f_i(j)*C1 of any t_i of its guardians.
+// The Index of each partial decryption is the guardian's index.
+func (c DkgContribution) ReconstructPartialDecryption(partials []common.PartialDecryption, curve elliptic.Curve) (common.Point, error) {
+	partials = lo.UniqBy(lo.Filter(partials, func(p common.PartialDecryption, _ int) bool { return lo.Contains(c.Guardians, p.Index) }), func(p common.PartialDecryption) int { return p.Index })
+	if len(partials) < c.Threshold {
+		return common.Point{}, fmt.Errorf("party %d: %w: %d of %d", c.Index, ErrNotEnoughShares, len(partials), c.Threshold)
+	}
+	partials = partials[:c.Threshold]
+	X := lo.Map(partials, func(p common.PartialDecryption, _ int) int { return p.Index })
+	lagrange := lo.Map(partials, func(_ common.PartialDecryption, i int) big.Int {
+		return *sss.LagrangeCoefficientsStartFromOne(i, 0, X, curve)
+	})
+	values := lo.Map(partials, func(p common.PartialDecryption, _ int) common.Point { return p.Value })
+	return utils.MultiScalarMult(lagrange, values, curve), nil
+}
@@ -0,0 +1,155 @@
+package pki
+
+import (
+	"errors"
+	"math/big"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/delendum-xyz/private-voting/fdkg/common"
+	"github.com/delendum-xyz/private-voting/fdkg/sss"
+	"github.com/delendum-xyz/private-voting/fdkg/utils"
+	"github.com/samber/lo"
+)
+
+func TestPerPartyThreshold(t *testing.T) {
+	config := common.VotingConfig{
+		Size:             8,
+		Options:          2,
+		Threshold:        2,
+		GuardiansSize:    3,
+		MinThreshold:     1,
+		MaxThreshold:     5,
+		MinGuardiansSize: 2,
+		MaxGuardiansSize: 6,
+	}
+	r := rand.New(rand.NewSource(0))
+	nodes := CreateRandomNodes(config, curve, r)
+	guardians := lo.Map(nodes, func(n LocalParty, _ int) PublicParty { return n.PublicParty })
+
+	for _, choice := range [][2]int{{1, 2}, {2, 4}, {3, 3}, {5, 6}} {
+		threshold, guardiansSize := choice[0], choice[1]
+		party, err := NewLocalPartyWithThreshold(9, config, threshold, guardiansSize, curve, r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dkgParty := party.ToDkgParty(UniformSelector{}.Select(party.PublicParty, guardians, guardiansSize, r))
+		contribution := dkgParty.Contribution(curve)
+		if err := contribution.Validate(config); err != nil {
+			t.Fatal(err)
+		}
+		if contribution.Threshold != threshold || len(contribution.Guardians) != guardiansSize {
+			t.Errorf("Expected (%d, %d) in contribution, got (%d, %d)", threshold, guardiansSize, contribution.Threshold, len(contribution.Guardians))
+		}
+
+		shares := dkgParty.GenerateShares(curve)
+		for _, share := range shares {
+			if !contribution.VerifyShare(share, curve) {
+				t.Errorf("(%d, %d): valid share %v was rejected", threshold, guardiansSize, share)
+			}
+		}
+		tampered := shares[0]
+		tampered.Value = *new(big.Int).Add(&tampered.Value, big.NewInt(1))
+		if contribution.VerifyShare(tampered, curve) {
+			t.Errorf("(%d, %d): tampered share was accepted", threshold, guardiansSize)
+		}
+
+		// any t_i shares are enough, t_i - 1 are not
+		secret, err := contribution.ReconstructSecret(shares[guardiansSize-threshold:], curve)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if secret.Cmp(&party.VotingPrivKeyShare) != 0 {
+			t.Errorf("(%d, %d): reconstructed a different secret", threshold, guardiansSize)
+		}
+		if _, err := contribution.ReconstructSecret(shares[:threshold-1], curve); !errors.Is(err, ErrNotEnoughShares) {
+			t.Errorf("(%d, %d): expected ErrNotEnoughShares, got %v", threshold, guardiansSize, err)
+		}
+
+		// the same holds in the exponent for partial decryptions of C1
+		c := utils.RandomBigInt(curve, r)
+		C1 := common.BigIntToPoint(curve.ScalarBaseMult(c.Bytes()))
+		partials := lo.Map(shares[guardiansSize-threshold:], func(s sss.Share, _ int) common.PartialDecryption {
+			return common.PartialDecryption{Index: s.To, Value: common.BigIntToPoint(curve.ScalarMult(&C1.X, &C1.Y, s.Value.Bytes()))}
+		})
+		Z, err := contribution.ReconstructPartialDecryption(partials, curve)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := common.BigIntToPoint(curve.ScalarMult(&C1.X, &C1.Y, party.VotingPrivKeyShare.Bytes()))
+		if !Z.Equal(expected) {
+			t.Errorf("(%d, %d): reconstructed partial decryption differs", threshold, guardiansSize)
+		}
+	}
+}
+
+func TestThresholdOutOfBounds(t *testing.T) {
+	config := common.VotingConfig{
+		Size:             8,
+		Options:          2,
+		Threshold:        2,
+		GuardiansSize:    3,
+		MinThreshold:     2,
+		MaxGuardiansSize: 4,
+	}
+	r := rand.New(rand.NewSource(0))
+	for _, choice := range [][2]int{{1, 3}, {3, 2}, {2, 5}, {0, 0}} {
+		if _, err := NewLocalPartyWithThreshold(1, config, choice[0], choice[1], curve, r); !errors.Is(err, common.ErrGuardianSetOutOfBounds) {
+			t.Errorf("(%d, %d): expected ErrGuardianSetOutOfBounds, got %v", choice[0], choice[1], err)
+		}
+	}
+}
+
+func TestDecryptionShares(t *testing.T) {
+	config := common.VotingConfig{
+		Size:          5,
+		Options:       2,
+		Threshold:     2,
+		GuardiansSize: 3,
+	}
+	r := rand.New(rand.NewSource(0))
+	_, dkgNodes, err := GenerateSetOfNodes(config, 1, UniformSelector{}, curve, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	party := dkgNodes[0]
+	contribution := party.Contribution(curve)
+	k := utils.RandomBigInt(curve, r)
+	C1 := common.BigIntToPoint(curve.ScalarBaseMult(k.Bytes()))
+
+	own := party.PartialDecryption(C1, curve)
+	if !contribution.VerifyPartialDecryption(own, C1, curve) {
+		t.Errorf("Valid partial decryption rejected")
+	}
+	batch := []DecryptionShare{own}
+	publicKeys := []common.Point{contribution.VotingPublicKey}
+	for _, share := range party.GenerateShares(curve) {
+		d := ProveDecryptionShare(share.To, share.Value, C1, config.ElectionID(), curve)
+		batch = append(batch, d)
+		publicKeys = append(publicKeys, contribution.ExpectedShare(share.To, curve))
+		if !contribution.VerifyGuardianDecryption(d, C1, curve) {
+			t.Errorf("Valid decryption of guardian %d rejected", share.To)
+		}
+		replayed := ProveDecryptionShare(share.To, share.Value, C1, common.VotingConfig{Name: "other"}.ElectionID(), curve)
+		if contribution.VerifyGuardianDecryption(replayed, C1, curve) {
+			t.Errorf("Decryption of guardian %d for another election accepted", share.To)
+		}
+		if contribution.VerifyPartialDecryption(d, C1, curve) {
+			t.Errorf("Guardian %d decryption accepted as the tallier's", share.To)
+		}
+	}
+
+	if invalid := BatchVerifyDecryptionShares(batch, publicKeys, C1, config.ElectionID(), curve); invalid != nil {
+		t.Errorf("Valid decryption shares %v rejected in a batch", invalid)
+	}
+
+	own.Value = common.BigIntToPoint(curve.Add(&own.Value.X, &own.Value.Y, curve.Params().Gx, curve.Params().Gy))
+	if contribution.VerifyPartialDecryption(own, C1, curve) {
+		t.Errorf("Tampered partial decryption accepted")
+	}
+	batch[0] = own
+	if invalid := BatchVerifyDecryptionShares(batch, publicKeys, C1, config.ElectionID(), curve); !reflect.DeepEqual(invalid, []int{0}) {
+		t.Errorf("Expected the tampered share to be rejected in a batch, got %v", invalid)
+	}
+}
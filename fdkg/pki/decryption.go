@@ -0,0 +1,133 @@
+package pki
+
+import (
+	"crypto"
+	"crypto/elliptic"
+	_ "crypto/sha256"
+	"math/big"
+	"sort"
+
+	"github.com/delendum-xyz/private-voting/fdkg/common"
+	"github.com/delendum-xyz/private-voting/fdkg/dleq"
+	"github.com/delendum-xyz/private-voting/fdkg/utils"
+	"github.com/samber/lo"
+)
+
+// DecryptionShare is a partial decryption x*C1 published with a DLEQ proof
+// that x is also the discrete log of the matching public key x*G, so a wrong
+// partial decryption can be detected and attributed to its author. The proof
+// is bound to the election and does not verify in any other.
+type DecryptionShare struct {
+	common.PartialDecryption
+	Proof dleq.DLEQProof
+}
+
+func decryptionStatement(publicKey, C1, value common.Point, election common.Hash, curve elliptic.Curve) dleq.DLEQ {
+	G := common.BigIntToPoint(curve.Params().Gx, curve.Params().Gy)
+	return dleq.DLEQ{G1: &G, H1: &publicKey, G2: &C1, H2: &value, Context: election[:]}
+}
+
+// ProveDecryptionShare computes secret*C1 and proves it against secret*G. The
+// proof's nonce comes from crypto/rand, as anyone who can predict it learns
+// the secret from the proof.
+func ProveDecryptionShare(index int, secret big.Int, C1 common.Point, election common.Hash, curve elliptic.Curve) DecryptionShare {
+	value := common.BigIntToPoint(curve.ScalarMult(&C1.X, &C1.Y, secret.Bytes()))
+	publicKey := common.BigIntToPoint(curve.ScalarBaseMult(secret.Bytes()))
+	w := utils.RandomBigIntCrypto(curve)
+	proof := dleq.NewProof(&w, &secret, decryptionStatement(publicKey, C1, value, election, curve), crypto.SHA256, curve)
+	return DecryptionShare{
+		PartialDecryption: common.PartialDecryption{Index: index, Value: value},
+		Proof:             proof,
+	}
+}
+
+// RestoreDecryptionShare rebuilds a published decryption share from its
+// value and the proof's challenge c and response z.
+func RestoreDecryptionShare(index int, value common.Point, c, z big.Int, curve elliptic.Curve) DecryptionShare {
+	return DecryptionShare{
+		PartialDecryption: common.PartialDecryption{Index: index, Value: value},
+		Proof:             dleq.RestoreProof(&z, &c, crypto.SHA256, curve),
+	}
+}
+
+// Verify checks the share is publicKey's discrete log applied to C1 in the
+// election.
+func (d DecryptionShare) Verify(publicKey, C1 common.Point, election common.Hash, curve elliptic.Curve) bool {
+	if d.Proof.Z == nil || d.Proof.C == nil || !d.Value.IsOnCurve(curve) {
+		return false
+	}
+	return d.Proof.Verify(decryptionStatement(publicKey, C1, d.Value, election, curve), curve)
+}
+
+// PartialDecryption is the tallier's own d_i*C1.
+func (p DkgParty) PartialDecryption(C1 common.Point, curve elliptic.Curve) DecryptionShare {
+	return ProveDecryptionShare(p.Index, p.VotingPrivKeyShare, C1, p.config.ElectionID(), curve)
+}
+
+// VerifyPartialDecryption checks the tallier's own partial decryption against
+// its voting public key.
+func (c DkgContribution) VerifyPartialDecryption(d DecryptionShare, C1 common.Point, curve elliptic.Curve) bool {
+	return d.Index == c.Index && d.Verify(c.VotingPublicKey, C1, c.Election, curve)
+}
+
+// VerifyGuardianDecryption checks a guardian's f_i(j)*C1 against the share
+// commitment f_i(j)*G derived from this contribution.
+func (c DkgContribution) VerifyGuardianDecryption(d DecryptionShare, C1 common.Point, curve elliptic.Curve) bool {
+	if !lo.Contains(c.Guardians, d.Index) {
+		return false
+	}
+	return d.Verify(c.ExpectedShare(d.Index, curve), C1, c.Election, curve)
+}
+
+// CombineDecryptionShares computes Z = x*C1 for the voting key x, the sum of
+// the talliers' d_i*C1. The shares are keyed by the tallier they decrypt for:
+// its own d_i*C1, or else the f_i(j)*C1 of enough of its guardians. Every
+// share is checked against the tallier's contribution; invalid ones are
+// skipped.
+func CombineDecryptionShares(C1 common.Point, contributions []DkgContribution, shares map[int][]DecryptionShare, curve elliptic.Curve) (common.Point, error) {
+	Z := common.PointZero()
+	for _, c := range contributions {
+		partial, err := c.combineDecryptionShares(C1, shares[c.Index], curve)
+		if err != nil {
+			return common.Point{}, err
+		}
+		Z = common.BigIntToPoint(curve.Add(&Z.X, &Z.Y, &partial.X, &partial.Y))
+	}
+	return Z, nil
+}
+
+func (c DkgContribution) combineDecryptionShares(C1 common.Point, shares []DecryptionShare, curve elliptic.Curve) (common.Point, error) {
+	var guardians []common.PartialDecryption
+	for _, d := range shares {
+		if c.VerifyPartialDecryption(d, C1, curve) {
+			return d.Value, nil
+		}
+		if c.VerifyGuardianDecryption(d, C1, curve) {
+			guardians = append(guardians, d.PartialDecryption)
+		}
+	}
+	return c.ReconstructPartialDecryption(guardians, curve)
+}
+
+// BatchVerifyDecryptionShares verifies many decryption shares of C1 at once,
+// share i against publicKeys[i], and returns the indices of the invalid ones,
+// or nil if all verify.
+func BatchVerifyDecryptionShares(shares []DecryptionShare, publicKeys []common.Point, C1 common.Point, election common.Hash, curve elliptic.Curve) []int {
+	statements := make([]dleq.DLEQ, len(shares))
+	proofs := make([]dleq.DLEQProof, len(shares))
+	var invalid []int
+	for i, d := range shares {
+		statements[i] = decryptionStatement(publicKeys[i], C1, d.Value, election, curve)
+		proofs[i] = d.Proof
+		if !d.Value.IsOnCurve(curve) {
+			invalid = append(invalid, i)
+		}
+	}
+	for _, i := range dleq.BatchVerify(statements, proofs, curve) {
+		if !lo.Contains(invalid, i) {
+			invalid = append(invalid, i)
+		}
+	}
+	sort.Ints(invalid)
+	return invalid
+}
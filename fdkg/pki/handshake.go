@@ -0,0 +1,208 @@
+package pki
+
+import (
+	"crypto/elliptic"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/rand"
+
+	"github.com/delendum-xyz/private-voting/fdkg/common"
+	"github.com/delendum-xyz/private-voting/fdkg/schnorr"
+	"github.com/samber/lo"
+)
+
+var (
+	ErrInvalidAnnouncement   = errors.New("announcement signature is invalid")
+	ErrDuplicateAnnouncement = errors.New("party has already announced itself")
+	ErrNotEnoughGuardians    = errors.New("not enough guardians accepted to reach the threshold")
+)
+
+// Announcement is the record a party publishes so that others can discover it
+// and learn its keys. It is signed with the party's PublicKey.
+type Announcement struct {
+	PublicParty
+	Signature schnorr.Signature
+}
+
+// GuardianRequest is sent by a tallier to a candidate guardian.
+type GuardianRequest struct {
+	Tallier  Announcement
+	Guardian int
+}
+
+// GuardianAcceptance is the guardian's signed consent to hold a share of the
+// tallier's VotingPrivKeyShare in the election, and in no other.
+type GuardianAcceptance struct {
+	Tallier   int
+	Guardian  PublicParty
+	Election  common.Hash
+	Signature schnorr.Signature
+}
+
+// ConsentPolicy is the guardian's local rule for accepting a tallier.
+type ConsentPolicy func(tallier PublicParty) bool
+
+// GuardianTransport delivers a request to a guardian and returns its answer;
+// ok is false if the guardian declined or did not answer.
+type GuardianTransport func(guardian PublicParty, request GuardianRequest) (acceptance GuardianAcceptance, ok bool)
+
+func AcceptAll(PublicParty) bool {
+	return true
+}
+
+func announcementMessage(p PublicParty, curve elliptic.Curve) []byte {
+	msg := []byte("fdkg/announce")
+	msg = binary.BigEndian.AppendUint64(msg, uint64(p.Index))
+	msg = append(msg, p.PublicKey.Marshal(curve)...)
+	return append(msg, p.VotingPublicKey.Marshal(curve)...)
+}
+
+func acceptanceMessage(tallier PublicParty, guardian PublicParty, election common.Hash, curve elliptic.Curve) []byte {
+	msg := []byte("fdkg/accept")
+	msg = append(msg, election[:]...)
+	msg = binary.BigEndian.AppendUint64(msg, uint64(tallier.Index))
+	msg = append(msg, tallier.PublicKey.Marshal(curve)...)
+	msg = binary.BigEndian.AppendUint64(msg, uint64(guardian.Index))
+	return append(msg, guardian.PublicKey.Marshal(curve)...)
+}
+
+func (p LocalParty) Announce(curve elliptic.Curve) Announcement {
+	return Announcement{
+		PublicParty: p.PublicParty,
+		Signature:   schnorr.Sign(p.PrivateKey, announcementMessage(p.PublicParty, curve), curve),
+	}
+}
+
+func (a Announcement) Verify(curve elliptic.Curve) bool {
+	return schnorr.Verify(a.PublicKey, announcementMessage(a.PublicParty, curve), a.Signature, curve)
+}
+
+func (a GuardianAcceptance) Verify(tallier PublicParty, election common.Hash, curve elliptic.Curve) bool {
+	if a.Tallier != tallier.Index || a.Election != election {
+		return false
+	}
+	return schnorr.Verify(a.Guardian.PublicKey, acceptanceMessage(tallier, a.Guardian, election, curve), a.Signature, curve)
+}
+
+// HandleGuardianRequest is run by the guardian. It checks that the request is
+// addressed to it and comes from a validly announced tallier before asking the
+// consent policy.
+func (p LocalParty) HandleGuardianRequest(request GuardianRequest, consent ConsentPolicy, curve elliptic.Curve) (GuardianAcceptance, bool) {
+	if request.Guardian != p.Index || request.Tallier.Index == p.Index || !request.Tallier.Verify(curve) {
+		return GuardianAcceptance{}, false
+	}
+	if !consent(request.Tallier.PublicParty) {
+		return GuardianAcceptance{}, false
+	}
+	election := p.config.ElectionID()
+	return GuardianAcceptance{
+		Tallier:   request.Tallier.Index,
+		Guardian:  p.PublicParty,
+		Election:  election,
+		Signature: schnorr.Sign(p.PrivateKey, acceptanceMessage(request.Tallier.PublicParty, p.PublicParty, election, curve), curve),
+	}, true
+}
+
+// Directory collects the announcements published on the network and serves
+// them as guardian candidates.
+type Directory struct {
+	announcements []Announcement
+	byIndex       map[int]int
+}
+
+func NewDirectory() *Directory {
+	return &Directory{byIndex: make(map[int]int)}
+}
+
+func (d *Directory) Publish(a Announcement, curve elliptic.Curve) error {
+	if !a.Verify(curve) {
+		return fmt.Errorf("party %d: %w", a.Index, ErrInvalidAnnouncement)
+	}
+	if _, ok := d.byIndex[a.Index]; ok {
+		return fmt.Errorf("party %d: %w", a.Index, ErrDuplicateAnnouncement)
+	}
+	d.byIndex[a.Index] = len(d.announcements)
+	d.announcements = append(d.announcements, a)
+	return nil
+}
+
+func (d *Directory) Lookup(index int) (PublicParty, bool) {
+	i, ok := d.byIndex[index]
+	if !ok {
+		return PublicParty{}, false
+	}
+	return d.announcements[i].PublicParty, true
+}
+
+func (d *Directory) Candidates() []PublicParty {
+	return lo.Map(d.announcements, func(a Announcement, _ int) PublicParty { return a.PublicParty })
+}
+
+// NegotiateGuardians runs the tallier's side of the handshake. It keeps asking
+// candidates chosen by the selector until k of them have accepted or there is
+// nobody left to ask. Only guardians that returned a valid acceptance end up
+// in TrustedParties.
+func (p LocalParty) NegotiateGuardians(directory *Directory, selector GuardianSelector, k int, send GuardianTransport, curve elliptic.Curve, r *rand.Rand) (DkgParty, error) {
+	request := p.Announce(curve)
+	asked := map[int]bool{p.Index: true}
+	accepted := make([]PublicParty, 0, k)
+
+	for len(accepted) < k {
+		candidates := lo.Filter(directory.Candidates(), func(c PublicParty, _ int) bool { return !asked[c.Index] })
+		if len(candidates) == 0 {
+			break
+		}
+		chosen := selector.Select(p.PublicParty, candidates, k-len(accepted), r)
+		if len(chosen) == 0 {
+			break
+		}
+		for _, guardian := range chosen {
+			if asked[guardian.Index] {
+				continue
+			}
+			asked[guardian.Index] = true
+			acceptance, ok := send(guardian, GuardianRequest{Tallier: request, Guardian: guardian.Index})
+			if !ok || acceptance.Guardian.Index != guardian.Index || !acceptance.Guardian.PublicKey.Equal(guardian.PublicKey) {
+				continue
+			}
+			if acceptance.Verify(p.PublicParty, p.config.ElectionID(), curve) {
+				accepted = append(accepted, guardian)
+			}
+		}
+	}
+
+	dkgParty := p.ToDkgParty(accepted)
+	if len(accepted) < p.Threshold {
+		return dkgParty, fmt.Errorf("party %d has %d of %d guardians: %w", p.Index, len(accepted), p.Threshold, ErrNotEnoughGuardians)
+	}
+	return dkgParty, nil
+}
+
+// NegotiateSetOfNodes is the networked counterpart of GenerateSetOfNodes: every
+// party announces itself in a shared directory and n_dkg talliers obtain their
+// guardians through the handshake, with requests delivered in memory.
+func NegotiateSetOfNodes(config common.VotingConfig, n_dkg int, selector GuardianSelector, consent ConsentPolicy, curve elliptic.Curve, r *rand.Rand) ([]LocalParty, []DkgParty, error) {
+	localNodes := CreateRandomNodes(config, curve, r)
+	directory := NewDirectory()
+	for _, node := range localNodes {
+		if err := directory.Publish(node.Announce(curve), curve); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	byIndex := lo.KeyBy(localNodes, func(node LocalParty) int { return node.Index })
+	send := func(guardian PublicParty, request GuardianRequest) (GuardianAcceptance, bool) {
+		return byIndex[guardian.Index].HandleGuardianRequest(request, consent, curve)
+	}
+
+	dkgNodes := make([]DkgParty, 0, n_dkg)
+	for _, node := range sampleParties(localNodes, n_dkg, r) {
+		dkgNode, err := node.NegotiateGuardians(directory, selector, node.GuardiansSize, send, curve, r)
+		if err != nil {
+			return nil, nil, err
+		}
+		dkgNodes = append(dkgNodes, dkgNode)
+	}
+	return localNodes, dkgNodes, nil
+}
@@ -0,0 +1,153 @@
+package pki
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/delendum-xyz/private-voting/fdkg/common"
+	"github.com/samber/lo"
+	"github.com/torusresearch/pvss/secp256k1"
+)
+
+var curve = secp256k1.Curve
+
+func TestNegotiateSetOfNodes(t *testing.T) {
+	config := common.VotingConfig{
+		Size:          6,
+		Options:       2,
+		Threshold:     2,
+		GuardiansSize: 3,
+	}
+	for i := 0; i < 10; i++ {
+		r := rand.New(rand.NewSource(int64(i)))
+		_, dkgNodes, err := NegotiateSetOfNodes(config, 4, UniformSelector{}, AcceptAll, curve, r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, node := range dkgNodes {
+			if len(node.TrustedParties) != config.GuardiansSize {
+				t.Errorf("Party_%d expected %d guardians, got %d", node.Index, config.GuardiansSize, len(node.TrustedParties))
+			}
+			if lo.ContainsBy(node.TrustedParties, func(p PublicParty) bool { return p.Index == node.Index }) {
+				t.Errorf("Party_%d is its own guardian", node.Index)
+			}
+		}
+	}
+}
+
+func TestNegotiateGuardiansSkipsDeclined(t *testing.T) {
+	config := common.VotingConfig{
+		Size:          6,
+		Options:       2,
+		Threshold:     2,
+		GuardiansSize: 3,
+	}
+	r := rand.New(rand.NewSource(0))
+	nodes := CreateRandomNodes(config, curve, r)
+	directory := NewDirectory()
+	for _, node := range nodes {
+		if err := directory.Publish(node.Announce(curve), curve); err != nil {
+			t.Fatal(err)
+		}
+	}
+	send := func(guardian PublicParty, request GuardianRequest) (GuardianAcceptance, bool) {
+		// party 2 refuses everyone
+		consent := func(PublicParty) bool { return guardian.Index != 2 }
+		return nodes[guardian.Index-1].HandleGuardianRequest(request, consent, curve)
+	}
+
+	dkgNode, err := nodes[0].NegotiateGuardians(directory, UniformSelector{}, config.GuardiansSize, send, curve, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dkgNode.TrustedParties) != config.GuardiansSize {
+		t.Errorf("Expected %d guardians, got %d", config.GuardiansSize, len(dkgNode.TrustedParties))
+	}
+	if lo.ContainsBy(dkgNode.TrustedParties, func(p PublicParty) bool { return p.Index == 2 }) {
+		t.Errorf("Party_2 declined but was made a guardian")
+	}
+}
+
+func TestNegotiateGuardiansRejectsForgedAcceptance(t *testing.T) {
+	config := common.VotingConfig{
+		Size:          4,
+		Options:       2,
+		Threshold:     2,
+		GuardiansSize: 3,
+	}
+	r := rand.New(rand.NewSource(0))
+	nodes := CreateRandomNodes(config, curve, r)
+	directory := NewDirectory()
+	for _, node := range nodes {
+		if err := directory.Publish(node.Announce(curve), curve); err != nil {
+			t.Fatal(err)
+		}
+	}
+	forger := NewLocalParty(99, config, curve, r)
+	send := func(guardian PublicParty, request GuardianRequest) (GuardianAcceptance, bool) {
+		// the forger answers in the guardian's name with its own key
+		acceptance, ok := forger.HandleGuardianRequest(GuardianRequest{Tallier: request.Tallier, Guardian: forger.Index}, AcceptAll, curve)
+		acceptance.Guardian.Index = guardian.Index
+		return acceptance, ok
+	}
+
+	dkgNode, err := nodes[0].NegotiateGuardians(directory, UniformSelector{}, config.GuardiansSize, send, curve, r)
+	if !errors.Is(err, ErrNotEnoughGuardians) {
+		t.Errorf("Expected ErrNotEnoughGuardians, got %v", err)
+	}
+	if len(dkgNode.TrustedParties) != 0 {
+		t.Errorf("Expected no guardians, got %v", dkgNode.TrustedParties)
+	}
+}
+
+func TestDirectoryRejectsInvalidAnnouncement(t *testing.T) {
+	config := common.VotingConfig{
+		Size:          2,
+		Options:       2,
+		Threshold:     1,
+		GuardiansSize: 1,
+	}
+	r := rand.New(rand.NewSource(0))
+	alice := NewLocalParty(1, config, curve, r)
+	bob := NewLocalParty(2, config, curve, r)
+
+	announcement := alice.Announce(curve)
+	announcement.VotingPublicKey = bob.VotingPublicKey
+
+	directory := NewDirectory()
+	if err := directory.Publish(announcement, curve); !errors.Is(err, ErrInvalidAnnouncement) {
+		t.Errorf("Expected ErrInvalidAnnouncement, got %v", err)
+	}
+	if err := directory.Publish(alice.Announce(curve), curve); err != nil {
+		t.Fatal(err)
+	}
+	if err := directory.Publish(alice.Announce(curve), curve); !errors.Is(err, ErrDuplicateAnnouncement) {
+		t.Errorf("Expected ErrDuplicateAnnouncement, got %v", err)
+	}
+}
+
+func TestGuardianAcceptanceIsBoundToElection(t *testing.T) {
+	config := common.VotingConfig{
+		Size:          2,
+		Options:       2,
+		Threshold:     1,
+		GuardiansSize: 1,
+	}
+	r := rand.New(rand.NewSource(0))
+	alice := NewLocalParty(1, config, curve, r)
+	bob := NewLocalParty(2, config, curve, r)
+
+	acceptance, ok := bob.HandleGuardianRequest(GuardianRequest{Tallier: alice.Announce(curve), Guardian: bob.Index}, AcceptAll, curve)
+	if !ok || !acceptance.Verify(alice.PublicParty, config.ElectionID(), curve) {
+		t.Fatal("Expected a valid acceptance")
+	}
+	other := common.VotingConfig{Name: "other"}.ElectionID()
+	if acceptance.Verify(alice.PublicParty, other, curve) {
+		t.Errorf("Acceptance verified in another election")
+	}
+	acceptance.Election = other
+	if acceptance.Verify(alice.PublicParty, other, curve) {
+		t.Errorf("Acceptance moved to another election verified")
+	}
+}
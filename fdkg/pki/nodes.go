@@ -3,25 +3,36 @@ package pki
 import (
 	"crypto/elliptic"
 	"fmt"
+	"io"
 	"math/big"
 	"math/rand"
+	"os"
 
 	"github.com/delendum-xyz/private-voting/fdkg/common"
 	"github.com/delendum-xyz/private-voting/fdkg/elgamal"
+	"github.com/delendum-xyz/private-voting/fdkg/eligibility"
 	"github.com/delendum-xyz/private-voting/fdkg/polynomial"
+	"github.com/delendum-xyz/private-voting/fdkg/schnorr"
 	"github.com/delendum-xyz/private-voting/fdkg/sss"
 	"github.com/delendum-xyz/private-voting/fdkg/utils"
 	"github.com/samber/lo"
-	"github.com/torusresearch/pvss/secp256k1"
 )
 
+// Output receives the progress messages printed while parties are created and
+// vote. Simulations running many elections can set it to io.Discard.
+var Output io.Writer = os.Stdout
+
 type LocalParty struct {
 	PublicParty
 	PrivateKey         big.Int
 	VotingPrivKeyShare big.Int
 	Polynomial         polynomial.Polynomial
-	vote               int
-	config             common.VotingConfig
+	// Threshold (t_i) and GuardiansSize (k_i) are this party's own choice for
+	// sharing its VotingPrivKeyShare.
+	Threshold     int
+	GuardiansSize int
+	vote          int
+	config        common.VotingConfig
 }
 
 type PublicParty struct {
@@ -36,22 +47,35 @@ type DkgParty struct {
 }
 
 func NewLocalParty(index int, config common.VotingConfig, curve elliptic.Curve, r *rand.Rand) LocalParty {
+	return newLocalParty(index, config, config.Threshold, config.GuardiansSize, curve, r)
+}
+
+// NewLocalPartyWithThreshold creates a party that shares its voting key with
+// its own threshold t_i among k_i guardians, within the election bounds.
+func NewLocalPartyWithThreshold(index int, config common.VotingConfig, threshold, guardiansSize int, curve elliptic.Curve, r *rand.Rand) (LocalParty, error) {
+	if err := config.ValidateGuardianSet(threshold, guardiansSize); err != nil {
+		return LocalParty{}, fmt.Errorf("party %d: %w", index, err)
+	}
+	return newLocalParty(index, config, threshold, guardiansSize, curve, r), nil
+}
+
+func newLocalParty(index int, config common.VotingConfig, threshold, guardiansSize int, curve elliptic.Curve, r *rand.Rand) LocalParty {
 	if index < 1 {
 		panic("index must be greater than 0")
 	}
 	privateKey := utils.RandomBigInt(curve, r)
-	publicKey := common.BigIntToPoint(secp256k1.Curve.ScalarBaseMult(privateKey.Bytes()))
-	if !secp256k1.Curve.IsOnCurve(&publicKey.X, &publicKey.Y) {
+	publicKey := common.BigIntToPoint(curve.ScalarBaseMult(privateKey.Bytes()))
+	if !curve.IsOnCurve(&publicKey.X, &publicKey.Y) {
 		panic("publicKey is not on curve")
 	}
 
 	votingPrivKeyShare := utils.RandomBigInt(curve, r)
-	votingPubKeyShare := common.BigIntToPoint(secp256k1.Curve.ScalarBaseMult(votingPrivKeyShare.Bytes()))
-	if !secp256k1.Curve.IsOnCurve(&votingPubKeyShare.X, &votingPubKeyShare.Y) {
+	votingPubKeyShare := common.BigIntToPoint(curve.ScalarBaseMult(votingPrivKeyShare.Bytes()))
+	if !curve.IsOnCurve(&votingPubKeyShare.X, &votingPubKeyShare.Y) {
 		panic("votingPubKeyShare is not on curve")
 	}
 
-	polynomial := polynomial.RandomPolynomialForSecret(votingPrivKeyShare, config.Threshold, curve, r)
+	polynomial := polynomial.RandomPolynomialForSecret(votingPrivKeyShare, threshold, curve, r)
 
 	return LocalParty{
 		PublicParty: PublicParty{
@@ -62,16 +86,89 @@ func NewLocalParty(index int, config common.VotingConfig, curve elliptic.Curve,
 		PrivateKey:         privateKey,
 		VotingPrivKeyShare: votingPrivKeyShare,
 		Polynomial:         polynomial,
+		Threshold:          threshold,
+		GuardiansSize:      guardiansSize,
 		vote:               index % config.Options,
 		config:             config,
 	}
 }
 
+// RestoreLocalParty rebuilds a party from persisted secrets.
+func RestoreLocalParty(index int, privateKey big.Int, votingPrivKeyShare big.Int, polynomial polynomial.Polynomial, guardiansSize int, vote int, config common.VotingConfig, curve elliptic.Curve) LocalParty {
+	return LocalParty{
+		PublicParty: PublicParty{
+			Index:           index,
+			PublicKey:       common.BigIntToPoint(curve.ScalarBaseMult(privateKey.Bytes())),
+			VotingPublicKey: common.BigIntToPoint(curve.ScalarBaseMult(votingPrivKeyShare.Bytes())),
+		},
+		PrivateKey:         privateKey,
+		VotingPrivKeyShare: votingPrivKeyShare,
+		Polynomial:         polynomial,
+		Threshold:          len(polynomial.Coefficients()),
+		GuardiansSize:      guardiansSize,
+		vote:               vote,
+		config:             config,
+	}
+}
+
+func (p LocalParty) Vote() int {
+	return p.vote
+}
+
+func (p LocalParty) Config() common.VotingConfig {
+	return p.config
+}
+
+// Nullifier is keccak256(sk || eid || "cast"): the same for every ballot of
+// the party in this election and different in every other election. It is
+// not anonymous: a CastBallot and a board membership proof publish the public
+// key next to it. What it provides is a stable handle for the voter's ballots,
+// so that a revote replaces the earlier ballot and a second one is not
+// counted twice.
+func (p LocalParty) Nullifier() common.Nullifier {
+	var nullifier common.Nullifier
+	election := p.config.ElectionID()
+	copy(nullifier[:], utils.Keccak256(p.PrivateKey.FillBytes(make([]byte, 32)), election[:], []byte("cast")))
+	return nullifier
+}
+
 func (p LocalParty) EncryptedBallot(encryptionKey common.Point, curve elliptic.Curve, r *rand.Rand) common.EncryptedBallot {
-	fmt.Printf("Party_%d voting %v, options: %v\n", p.Index, p.vote, p.config.Options)
+	fmt.Fprintf(Output, "Party_%d voting %v, options: %v\n", p.Index, p.vote, p.config.Options)
 	return elgamal.EncryptBallot(p.vote, p.config.Options, encryptionKey, curve, r)
 }
 
+// EncryptedBallotWith encrypts the party's vote with the election's
+// precomputed tables.
+func (p LocalParty) EncryptedBallotWith(tables *elgamal.Tables, r *rand.Rand) common.EncryptedBallot {
+	fmt.Fprintf(Output, "Party_%d voting %v, options: %v\n", p.Index, p.vote, p.config.Options)
+	return tables.EncryptBallot(p.vote, p.config.Options, r)
+}
+
+// CastBallot is a ballot as published under its voter's nullifier, signed
+// with the voter's key so that nobody else can cast or replace a ballot under
+// that nullifier.
+type CastBallot struct {
+	PublicKey common.Point
+	Nullifier common.Nullifier
+	Ballot    common.EncryptedBallot
+	Signature schnorr.Signature
+}
+
+// Cast signs the ballot under the party's nullifier.
+func (p LocalParty) Cast(ballot common.EncryptedBallot, curve elliptic.Curve, r *rand.Rand) CastBallot {
+	nullifier := p.Nullifier()
+	return CastBallot{
+		PublicKey: p.PublicKey,
+		Nullifier: nullifier,
+		Ballot:    ballot,
+		Signature: eligibility.SignBallot(p.PrivateKey, p.config.ElectionID(), nullifier, ballot, curve),
+	}
+}
+
+func (c CastBallot) Verify(election common.Hash, curve elliptic.Curve) bool {
+	return eligibility.VerifyBallotSignature(c.PublicKey, election, c.Nullifier, c.Ballot, c.Signature, curve)
+}
+
 func (p DkgParty) GenerateShares(curve elliptic.Curve) []sss.Share {
 	indices := lo.Map(p.TrustedParties, func(party PublicParty, _ int) int { return party.Index })
 	shares := sss.GenerateShares(p.Polynomial, p.Index, indices)
@@ -85,27 +182,6 @@ func (p LocalParty) ToDkgParty(trustedParties []PublicParty) DkgParty {
 	}
 }
 
-func randomTrustedParties(p LocalParty, publicNodes []PublicParty, threshold int) []PublicParty {
-	// take random subset of m nodes participating in DKG without the node itself
-	tempPublicNodes := make([]PublicParty, len(publicNodes))
-	copy(tempPublicNodes, publicNodes)
-	rand.Shuffle(len(tempPublicNodes), func(i, j int) { tempPublicNodes[i], tempPublicNodes[j] = tempPublicNodes[j], tempPublicNodes[i] })
-
-	trustedParties := make([]PublicParty, threshold)
-	parties := 0
-
-	for _, node := range tempPublicNodes {
-		if parties == threshold {
-			break
-		}
-		if node.Index != p.Index {
-			trustedParties[parties] = node
-			parties += 1
-		}
-	}
-	return trustedParties
-}
-
 func CreateRandomNodes(config common.VotingConfig, curve elliptic.Curve, r *rand.Rand) []LocalParty {
 	if config.Threshold > config.Size-1 {
 		panic("Threshold must be less than size-1 otherwise it's impossible to reconstruct the secret.")
@@ -114,12 +190,16 @@ func CreateRandomNodes(config common.VotingConfig, curve elliptic.Curve, r *rand
 	for i := range nodes {
 		newNode := NewLocalParty(i+1, config, curve, r)
 		nodes[i] = newNode
-		fmt.Printf("Party_%d voted %v of polynomial %v\n", newNode.Index, newNode.vote, newNode.Polynomial.String())
+		fmt.Fprintf(Output, "Party_%d voted %v of polynomial %v\n", newNode.Index, newNode.vote, newNode.Polynomial.String())
 	}
 	return nodes
 }
 
-func GenerateSetOfNodes(config common.VotingConfig, n_dkg int, curve elliptic.Curve, r *rand.Rand) ([]LocalParty, []DkgParty) {
+// GenerateSetOfNodes creates the parties of the election and lets n_dkg of
+// them pick their guardians with the selector. It fails, like
+// NewLocalPartyWithThreshold, if a party gets fewer guardians than its
+// threshold, as its share of the voting key could never be reconstructed.
+func GenerateSetOfNodes(config common.VotingConfig, n_dkg int, selector GuardianSelector, curve elliptic.Curve, r *rand.Rand) ([]LocalParty, []DkgParty, error) {
 	localNodes := CreateRandomNodes(config, curve, r)
 
 	publicNodes := make([]PublicParty, config.Size)
@@ -127,15 +207,15 @@ func GenerateSetOfNodes(config common.VotingConfig, n_dkg int, curve elliptic.Cu
 		publicNodes[i] = localNodes[i].PublicParty
 	}
 
-	tempPublicNodes := make([]LocalParty, len(localNodes))
-	copy(tempPublicNodes, localNodes)
-	rand.Shuffle(len(tempPublicNodes), func(i, j int) { tempPublicNodes[i], tempPublicNodes[j] = tempPublicNodes[j], tempPublicNodes[i] })
-
-	dkgCandidates := lo.Samples(localNodes, n_dkg)
-	dkgNodes := lo.Map(dkgCandidates, func(node LocalParty, index int) DkgParty {
-		trustedParties := randomTrustedParties(node, publicNodes, config.GuardiansSize)
-		fmt.Printf("Party_%d has following trusted parties %v \n", node.Index, lo.Map(trustedParties, func(party PublicParty, _ int) int { return party.Index }))
-		return node.ToDkgParty(trustedParties)
-	})
-	return localNodes, dkgNodes
+	dkgCandidates := sampleParties(localNodes, n_dkg, r)
+	dkgNodes := make([]DkgParty, len(dkgCandidates))
+	for i, node := range dkgCandidates {
+		trustedParties := selector.Select(node.PublicParty, publicNodes, node.GuardiansSize, r)
+		fmt.Fprintf(Output, "Party_%d has following trusted parties %v \n", node.Index, lo.Map(trustedParties, func(party PublicParty, _ int) int { return party.Index }))
+		if len(trustedParties) < node.Threshold {
+			return nil, nil, fmt.Errorf("party %d: %w: %d guardians selected for t_i=%d", node.Index, common.ErrGuardianSetOutOfBounds, len(trustedParties), node.Threshold)
+		}
+		dkgNodes[i] = node.ToDkgParty(trustedParties)
+	}
+	return localNodes, dkgNodes, nil
 }
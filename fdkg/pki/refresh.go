@@ -0,0 +1,178 @@
+package pki
+
+import (
+	"crypto/elliptic"
+	"errors"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"sort"
+
+	"github.com/delendum-xyz/private-voting/fdkg/common"
+	"github.com/delendum-xyz/private-voting/fdkg/polynomial"
+	"github.com/delendum-xyz/private-voting/fdkg/sss"
+	"github.com/samber/lo"
+)
+
+var ErrInvalidRefresh = errors.New("invalid share refresh")
+
+// Refresh deals the party's VotingPrivKeyShare afresh to a new guardian set,
+// possibly with a new threshold t_i. The new polynomial keeps f_i(0) and so
+// the VotingPublicKey, but its other coefficients are new: shares of the old
+// polynomial no longer verify against the new commitments and cannot be
+// combined with shares of the new one. Old guardians should still erase
+// their shares, since any t_i of them can recover the unchanged secret.
+func (p DkgParty) Refresh(guardians []PublicParty, threshold int, curve elliptic.Curve, r *rand.Rand) (DkgParty, error) {
+	if err := p.config.ValidateGuardianSet(threshold, len(guardians)); err != nil {
+		return DkgParty{}, fmt.Errorf("party %d: %w", p.Index, err)
+	}
+	if lo.ContainsBy(guardians, func(g PublicParty) bool { return g.Index == p.Index }) {
+		return DkgParty{}, fmt.Errorf("party %d: %w: party cannot guard itself", p.Index, ErrInvalidRefresh)
+	}
+	local := p.LocalParty
+	local.Polynomial = polynomial.RandomPolynomialForSecret(p.VotingPrivKeyShare, threshold, curve, r)
+	local.Threshold = threshold
+	local.GuardiansSize = len(guardians)
+	return local.ToDkgParty(guardians), nil
+}
+
+// VerifyRefresh checks that next is a well formed contribution of the same
+// party sharing the same VotingPublicKey.
+func (c DkgContribution) VerifyRefresh(next DkgContribution, config common.VotingConfig) error {
+	if next.Index != c.Index {
+		return fmt.Errorf("%w: refresh of party %d published for party %d", ErrInvalidRefresh, next.Index, c.Index)
+	}
+	if err := next.Validate(config); err != nil {
+		return err
+	}
+	if !next.VotingPublicKey.Equal(c.VotingPublicKey) {
+		return fmt.Errorf("party %d: %w: voting public key changed", c.Index, ErrInvalidRefresh)
+	}
+	return nil
+}
+
+// Reshare is what one old guardian j of dealer i publishes to refresh the
+// dealer's shares when the dealer itself is gone: Feldman commitments to a
+// polynomial g_j with g_j(0) = f_i(j), whose evaluations it sends to the new
+// guardians.
+type Reshare struct {
+	Election    common.Hash
+	Dealer      int
+	From        int
+	Guardians   []int
+	Commitments []common.Point
+}
+
+// Contribution views the reshare as a contribution of the old guardian, so
+// its shares can be checked with VerifyShare.
+func (rs Reshare) Contribution() DkgContribution {
+	c := DkgContribution{Election: rs.Election, Index: rs.From, Threshold: len(rs.Commitments), Guardians: rs.Guardians, Commitments: rs.Commitments}
+	if len(rs.Commitments) > 0 {
+		c.VotingPublicKey = rs.Commitments[0]
+	}
+	return c
+}
+
+// ReshareShare deals an old guardian's share of the dealer to the new
+// guardians with the new threshold. The returned shares go from the old
+// guardian to each new one.
+func (c DkgContribution) ReshareShare(share sss.Share, guardians []int, threshold int, config common.VotingConfig, curve elliptic.Curve, r *rand.Rand) (Reshare, []sss.Share, error) {
+	if !c.VerifyShare(share, curve) {
+		return Reshare{}, nil, fmt.Errorf("party %d: %w: share of guardian %d does not verify", c.Index, ErrInvalidRefresh, share.To)
+	}
+	next := DkgContribution{Index: c.Index, Threshold: threshold, Guardians: guardians}
+	if err := next.validateSets(config); err != nil {
+		return Reshare{}, nil, err
+	}
+	p := polynomial.RandomPolynomialForSecret(share.Value, threshold, curve, r)
+	reshare := Reshare{
+		Election:  c.Election,
+		Dealer:    c.Index,
+		From:      share.To,
+		Guardians: guardians,
+		Commitments: lo.Map(p.Coefficients(), func(coeff big.Int, _ int) common.Point {
+			return common.BigIntToPoint(curve.ScalarBaseMult(coeff.Bytes()))
+		}),
+	}
+	return reshare, sss.GenerateShares(p, share.To, guardians), nil
+}
+
+// VerifyReshare checks a reshare against the dealer's commitments: g_j(0)*G
+// must be the old guardian's share commitment f_i(j)*G.
+func (c DkgContribution) VerifyReshare(rs Reshare, curve elliptic.Curve) error {
+	if rs.Election != c.Election {
+		return fmt.Errorf("party %d: %w: reshare from %d", c.Index, common.ErrWrongElection, rs.From)
+	}
+	if rs.Dealer != c.Index || !lo.Contains(c.Guardians, rs.From) {
+		return fmt.Errorf("party %d: %w: reshare from %d is not from one of its guardians", c.Index, ErrInvalidRefresh, rs.From)
+	}
+	if len(rs.Commitments) == 0 || !lo.EveryBy(rs.Commitments, func(p common.Point) bool { return p.IsOnCurve(curve) }) {
+		return fmt.Errorf("party %d: %w: reshare from %d has invalid commitments", c.Index, ErrInvalidRefresh, rs.From)
+	}
+	if !rs.Commitments[0].Equal(c.ExpectedShare(rs.From, curve)) {
+		return fmt.Errorf("party %d: %w: reshare from %d does not share its committed share", c.Index, ErrInvalidRefresh, rs.From)
+	}
+	return nil
+}
+
+// QualifiedReshares picks the t_i valid reshares, by guardian index, that the
+// new guardians combine. Every reshare used must agree on the new guardian
+// set and threshold.
+func (c DkgContribution) QualifiedReshares(reshares []Reshare, curve elliptic.Curve) ([]Reshare, error) {
+	valid := lo.UniqBy(lo.Filter(reshares, func(rs Reshare, _ int) bool { return c.VerifyReshare(rs, curve) == nil }), func(rs Reshare) int { return rs.From })
+	if len(valid) < c.Threshold {
+		return nil, fmt.Errorf("party %d: %w: %d valid reshares of %d", c.Index, ErrNotEnoughShares, len(valid), c.Threshold)
+	}
+	sort.Slice(valid, func(i, j int) bool { return valid[i].From < valid[j].From })
+	qualified := valid[:c.Threshold]
+	for _, rs := range qualified[1:] {
+		if len(rs.Commitments) != len(qualified[0].Commitments) || len(rs.Guardians) != len(qualified[0].Guardians) || !lo.Every(rs.Guardians, qualified[0].Guardians) {
+			return nil, fmt.Errorf("party %d: %w: reshares disagree on the new guardian set", c.Index, ErrInvalidRefresh)
+		}
+	}
+	return qualified, nil
+}
+
+func reshareCoefficients(qualified []Reshare, curve elliptic.Curve) []*big.Int {
+	X := lo.Map(qualified, func(rs Reshare, _ int) int { return rs.From })
+	return lo.Map(qualified, func(_ Reshare, i int) *big.Int { return sss.LagrangeCoefficientsStartFromOne(i, 0, X, curve) })
+}
+
+// CombineReshares computes the dealer's refreshed contribution from the
+// qualified reshares: C'_k = sum_j lambda_j * G_jk, so that C'_0 is still the
+// VotingPublicKey.
+func (c DkgContribution) CombineReshares(qualified []Reshare, curve elliptic.Curve) DkgContribution {
+	lagrange := reshareCoefficients(qualified, curve)
+	commitments := make([]common.Point, len(qualified[0].Commitments))
+	for k := range commitments {
+		commitments[k] = common.PointZero()
+		for j, rs := range qualified {
+			X, Y := curve.ScalarMult(&rs.Commitments[k].X, &rs.Commitments[k].Y, lagrange[j].Bytes())
+			commitments[k] = common.BigIntToPoint(curve.Add(&commitments[k].X, &commitments[k].Y, X, Y))
+		}
+	}
+	return DkgContribution{
+		Election:        c.Election,
+		Index:           c.Index,
+		VotingPublicKey: commitments[0],
+		Threshold:       len(commitments),
+		Guardians:       qualified[0].Guardians,
+		Commitments:     commitments,
+	}
+}
+
+// CombineResharedShares is run by new guardian `to`: it checks the shares it
+// received from the qualified old guardians and combines them into its share
+// sum_j lambda_j * g_j(to) of the dealer's refreshed polynomial.
+func (c DkgContribution) CombineResharedShares(qualified []Reshare, received []sss.Share, to int, curve elliptic.Curve) (sss.Share, error) {
+	lagrange := reshareCoefficients(qualified, curve)
+	value := new(big.Int)
+	for j, rs := range qualified {
+		share, found := lo.Find(received, func(s sss.Share) bool { return s.From == rs.From && s.To == to })
+		if !found || !rs.Contribution().VerifyShare(share, curve) {
+			return sss.Share{}, fmt.Errorf("party %d: %w: no valid share from guardian %d for %d", c.Index, ErrInvalidRefresh, rs.From, to)
+		}
+		value.Add(value, new(big.Int).Mul(&share.Value, lagrange[j]))
+	}
+	return sss.Share{From: c.Index, To: to, Value: *value.Mod(value, curve.Params().N)}, nil
+}
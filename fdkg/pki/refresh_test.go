@@ -0,0 +1,142 @@
+package pki
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/delendum-xyz/private-voting/fdkg/common"
+	"github.com/delendum-xyz/private-voting/fdkg/sss"
+	"github.com/samber/lo"
+)
+
+// refreshSetup returns a tallier with guardians (t_i, k_i) = (2, 3) and a
+// disjoint set of four new guardians.
+func refreshSetup(t *testing.T) (common.VotingConfig, DkgParty, []PublicParty) {
+	config := common.VotingConfig{
+		Size:          8,
+		Options:       2,
+		Threshold:     2,
+		GuardiansSize: 3,
+	}
+	r := rand.New(rand.NewSource(0))
+	localNodes, dkgNodes, err := GenerateSetOfNodes(config, 1, UniformSelector{}, curve, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	party := dkgNodes[0]
+	newGuardians := lo.FilterMap(localNodes, func(node LocalParty, _ int) (PublicParty, bool) {
+		old := lo.ContainsBy(party.TrustedParties, func(g PublicParty) bool { return g.Index == node.Index })
+		return node.PublicParty, !old && node.Index != party.Index
+	})
+	if len(newGuardians) != 4 {
+		t.Fatalf("Expected 4 new guardians, got %d", len(newGuardians))
+	}
+	return config, party, newGuardians
+}
+
+func TestRefresh(t *testing.T) {
+	config, party, newGuardians := refreshSetup(t)
+	r := rand.New(rand.NewSource(1))
+	old := party.Contribution(curve)
+	oldShares := party.GenerateShares(curve)
+
+	refreshed, err := party.Refresh(newGuardians, 3, curve, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := refreshed.Contribution(curve)
+	if err := old.VerifyRefresh(next, config); err != nil {
+		t.Fatal(err)
+	}
+	shares := refreshed.GenerateShares(curve)
+	for _, share := range shares {
+		if !next.VerifyShare(share, curve) {
+			t.Errorf("Valid refreshed share %v rejected", share)
+		}
+	}
+	for _, share := range oldShares {
+		if next.VerifyShare(share, curve) {
+			t.Errorf("Old share %v accepted after the refresh", share)
+		}
+	}
+	secret, err := next.ReconstructSecret(shares[1:], curve)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret.Cmp(&party.VotingPrivKeyShare) != 0 {
+		t.Errorf("Refreshed shares reconstruct a different secret")
+	}
+	// old shares do not combine with new ones
+	mixed := lo.Map([]sss.Share{shares[0], shares[1], oldShares[0]}, func(s sss.Share, _ int) common.PrimaryShare { return s.ToPrimaryShare() })
+	if sss.LagrangeScalar(mixed, 0, curve).Cmp(&party.VotingPrivKeyShare) == 0 {
+		t.Errorf("Old and new shares reconstructed the secret together")
+	}
+
+	if _, err := party.Refresh(newGuardians, 5, curve, r); !errors.Is(err, common.ErrGuardianSetOutOfBounds) {
+		t.Errorf("Expected ErrGuardianSetOutOfBounds, got %v", err)
+	}
+	forged := next
+	forged.Commitments = append([]common.Point{next.Commitments[1]}, next.Commitments[1:]...)
+	forged.VotingPublicKey = forged.Commitments[0]
+	if err := old.VerifyRefresh(forged, config); !errors.Is(err, ErrInvalidRefresh) {
+		t.Errorf("Expected ErrInvalidRefresh for a changed key, got %v", err)
+	}
+}
+
+func TestReshareWithoutTallier(t *testing.T) {
+	config, party, newGuardians := refreshSetup(t)
+	r := rand.New(rand.NewSource(2))
+	old := party.Contribution(curve)
+	indices := lo.Map(newGuardians, func(g PublicParty, _ int) int { return g.Index })
+
+	var reshares []Reshare
+	var sent []sss.Share
+	for _, share := range party.GenerateShares(curve) {
+		reshare, shares, err := old.ReshareShare(share, indices, 3, config, curve, r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := old.VerifyReshare(reshare, curve); err != nil {
+			t.Fatal(err)
+		}
+		reshares = append(reshares, reshare)
+		sent = append(sent, shares...)
+	}
+	// a reshare of something else than the guardian's share is rejected
+	forged := reshares[0]
+	forged.Commitments = reshares[1].Commitments
+	if err := old.VerifyReshare(forged, curve); !errors.Is(err, ErrInvalidRefresh) {
+		t.Errorf("Expected ErrInvalidRefresh for a forged reshare, got %v", err)
+	}
+
+	qualified, err := old.QualifiedReshares(append([]Reshare{forged}, reshares[1:]...), curve)
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := old.CombineReshares(qualified, curve)
+	if err := old.VerifyRefresh(next, config); err != nil {
+		t.Fatal(err)
+	}
+	shares := lo.Map(indices, func(to int, _ int) sss.Share {
+		share, err := old.CombineResharedShares(qualified, sent, to, curve)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !next.VerifyShare(share, curve) {
+			t.Errorf("Combined share of %d does not verify", to)
+		}
+		return share
+	})
+	secret, err := next.ReconstructSecret(shares[:3], curve)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret.Cmp(&party.VotingPrivKeyShare) != 0 {
+		t.Errorf("Reshared shares reconstruct a different secret")
+	}
+
+	if _, err := old.QualifiedReshares(reshares[:1], curve); !errors.Is(err, ErrNotEnoughShares) {
+		t.Errorf("Expected ErrNotEnoughShares, got %v", err)
+	}
+}
@@ -0,0 +1,130 @@
+package pki
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/samber/lo"
+)
+
+// GuardianSelector picks up to k guardians for a party out of the candidates.
+// All randomness comes from r, so a selection is reproducible from its seed.
+// The party itself is never selected.
+type GuardianSelector interface {
+	Select(self PublicParty, candidates []PublicParty, k int, r *rand.Rand) []PublicParty
+}
+
+func withoutSelf(self PublicParty, candidates []PublicParty) []PublicParty {
+	return lo.Filter(candidates, func(c PublicParty, _ int) bool { return c.Index != self.Index })
+}
+
+func shuffled(parties []PublicParty, r *rand.Rand) []PublicParty {
+	result := make([]PublicParty, len(parties))
+	copy(result, parties)
+	r.Shuffle(len(result), func(i, j int) { result[i], result[j] = result[j], result[i] })
+	return result
+}
+
+func firstK(parties []PublicParty, k int) []PublicParty {
+	if k > len(parties) {
+		k = len(parties)
+	}
+	return parties[:k]
+}
+
+// UniformSelector picks k guardians uniformly at random.
+type UniformSelector struct{}
+
+func (UniformSelector) Select(self PublicParty, candidates []PublicParty, k int, r *rand.Rand) []PublicParty {
+	return firstK(shuffled(withoutSelf(self, candidates), r), k)
+}
+
+// TrustGraphSelector picks guardians among the party's neighbours in a social
+// or trust graph, keyed by party index. If the party has fewer than k
+// neighbours among the candidates, the remaining guardians are taken from
+// Fallback, or left out when Fallback is nil.
+type TrustGraphSelector struct {
+	Graph    map[int][]int
+	Fallback GuardianSelector
+}
+
+func (s TrustGraphSelector) Select(self PublicParty, candidates []PublicParty, k int, r *rand.Rand) []PublicParty {
+	neighbours := lo.SliceToMap(s.Graph[self.Index], func(index int) (int, bool) { return index, true })
+	trusted := lo.Filter(withoutSelf(self, candidates), func(c PublicParty, _ int) bool { return neighbours[c.Index] })
+	others := lo.Filter(withoutSelf(self, candidates), func(c PublicParty, _ int) bool { return !neighbours[c.Index] })
+
+	selected := firstK(shuffled(trusted, r), k)
+	if len(selected) < k && s.Fallback != nil {
+		selected = append(selected, s.Fallback.Select(self, others, k-len(selected), r)...)
+	}
+	return selected
+}
+
+// ReputationSelector samples k guardians without replacement with probability
+// proportional to their reputation. Parties without a score have weight 1;
+// parties with a non-positive score are never selected.
+type ReputationSelector struct {
+	Reputation map[int]float64
+}
+
+func (s ReputationSelector) weight(index int) float64 {
+	if w, ok := s.Reputation[index]; ok {
+		return w
+	}
+	return 1
+}
+
+func (s ReputationSelector) Select(self PublicParty, candidates []PublicParty, k int, r *rand.Rand) []PublicParty {
+	// Efraimidis-Spirakis: take the k largest keys u^(1/w), u ~ U(0,1)
+	eligible := lo.Filter(withoutSelf(self, candidates), func(c PublicParty, _ int) bool { return s.weight(c.Index) > 0 })
+	keys := make([]float64, len(eligible))
+	for i, c := range eligible {
+		keys[i] = math.Pow(r.Float64(), 1/s.weight(c.Index))
+	}
+	order := lo.Range(len(eligible))
+	sort.SliceStable(order, func(i, j int) bool { return keys[order[i]] > keys[order[j]] })
+	return firstK(lo.Map(order, func(i int, _ int) PublicParty { return eligible[i] }), k)
+}
+
+// LoadBalancedSelector avoids overloaded guardians: it prefers the candidates
+// that have been selected the fewest times so far and never selects one more
+// than MaxLoad times (0 means no limit). Ties are broken at random. It keeps
+// state between calls, so one selector should be shared by all parties of an
+// election. The zero value is a selector without a limit.
+type LoadBalancedSelector struct {
+	MaxLoad int
+	load    map[int]int
+}
+
+func NewLoadBalancedSelector(maxLoad int) *LoadBalancedSelector {
+	return &LoadBalancedSelector{MaxLoad: maxLoad, load: make(map[int]int)}
+}
+
+func (s *LoadBalancedSelector) Load(index int) int {
+	return s.load[index]
+}
+
+func (s *LoadBalancedSelector) Select(self PublicParty, candidates []PublicParty, k int, r *rand.Rand) []PublicParty {
+	available := lo.Filter(shuffled(withoutSelf(self, candidates), r), func(c PublicParty, _ int) bool {
+		return s.MaxLoad == 0 || s.load[c.Index] < s.MaxLoad
+	})
+	sort.SliceStable(available, func(i, j int) bool { return s.load[available[i].Index] < s.load[available[j].Index] })
+
+	if s.load == nil {
+		s.load = make(map[int]int)
+	}
+	selected := firstK(available, k)
+	for _, guardian := range selected {
+		s.load[guardian.Index]++
+	}
+	return selected
+}
+
+// sampleParties picks n parties at random using r.
+func sampleParties(parties []LocalParty, n int, r *rand.Rand) []LocalParty {
+	if n > len(parties) {
+		n = len(parties)
+	}
+	return lo.Map(r.Perm(len(parties))[:n], func(i int, _ int) LocalParty { return parties[i] })
+}
@@ -0,0 +1,141 @@
+package pki
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/delendum-xyz/private-voting/fdkg/common"
+	"github.com/samber/lo"
+)
+
+func publicParties(n int) []PublicParty {
+	return lo.Map(lo.Range(n), func(i int, _ int) PublicParty { return PublicParty{Index: i + 1} })
+}
+
+func indices(parties []PublicParty) []int {
+	return lo.Map(parties, func(p PublicParty, _ int) int { return p.Index })
+}
+
+func TestSelectionIsReproducibleFromSeed(t *testing.T) {
+	config := common.VotingConfig{
+		Size:          8,
+		Options:       2,
+		Threshold:     2,
+		GuardiansSize: 3,
+	}
+	selectors := map[string]func() GuardianSelector{
+		"uniform":    func() GuardianSelector { return UniformSelector{} },
+		"reputation": func() GuardianSelector { return ReputationSelector{Reputation: map[int]float64{1: 5, 2: 0.5}} },
+		"balanced":   func() GuardianSelector { return NewLoadBalancedSelector(4) },
+	}
+	for name, selector := range selectors {
+		_, first, err := GenerateSetOfNodes(config, 5, selector(), curve, rand.New(rand.NewSource(42)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, second, err := GenerateSetOfNodes(config, 5, selector(), curve, rand.New(rand.NewSource(42)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range first {
+			if first[i].Index != second[i].Index || !lo.Every(indices(first[i].TrustedParties), indices(second[i].TrustedParties)) {
+				t.Errorf("%s: Party_%d selection differs between runs with the same seed", name, first[i].Index)
+			}
+		}
+	}
+}
+
+func TestUniformSelectorExcludesSelf(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	candidates := publicParties(5)
+	for i := 0; i < 100; i++ {
+		selected := UniformSelector{}.Select(candidates[0], candidates, 4, r)
+		if len(selected) != 4 || lo.Contains(indices(selected), 1) {
+			t.Fatalf("Expected 4 guardians without Party_1, got %v", indices(selected))
+		}
+		if len(lo.Uniq(indices(selected))) != len(selected) {
+			t.Fatalf("Selected the same guardian twice: %v", indices(selected))
+		}
+	}
+}
+
+func TestTrustGraphSelector(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	candidates := publicParties(6)
+	graph := map[int][]int{1: {3, 5}}
+
+	selected := TrustGraphSelector{Graph: graph}.Select(candidates[0], candidates, 3, r)
+	if !lo.Every([]int{3, 5}, indices(selected)) || len(selected) != 2 {
+		t.Errorf("Expected only the neighbours 3 and 5, got %v", indices(selected))
+	}
+
+	selected = TrustGraphSelector{Graph: graph, Fallback: UniformSelector{}}.Select(candidates[0], candidates, 3, r)
+	if len(selected) != 3 || !lo.Every(indices(selected), []int{3, 5}) || lo.Contains(indices(selected), 1) {
+		t.Errorf("Expected neighbours 3 and 5 plus one fallback guardian, got %v", indices(selected))
+	}
+}
+
+func TestReputationSelectorPrefersReputable(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	candidates := publicParties(4)
+	selector := ReputationSelector{Reputation: map[int]float64{2: 100, 3: 1, 4: 0}}
+	counts := make(map[int]int)
+	for i := 0; i < 1000; i++ {
+		for _, guardian := range selector.Select(candidates[0], candidates, 1, r) {
+			counts[guardian.Index]++
+		}
+	}
+	if counts[4] != 0 {
+		t.Errorf("Party_4 has no reputation but was selected %d times", counts[4])
+	}
+	if counts[2] < 900 {
+		t.Errorf("Expected Party_2 to be selected most of the time, got %v", counts)
+	}
+}
+
+func TestLoadBalancedSelector(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	candidates := publicParties(6)
+	selector := NewLoadBalancedSelector(2)
+	for _, self := range candidates {
+		selector.Select(self, candidates, 2, r)
+	}
+	for _, c := range candidates {
+		if selector.Load(c.Index) != 2 {
+			t.Errorf("Expected Party_%d to guard exactly 2 parties, got %d", c.Index, selector.Load(c.Index))
+		}
+	}
+	if selected := selector.Select(PublicParty{Index: 7}, candidates, 2, r); len(selected) != 0 {
+		t.Errorf("Expected no guardian under the load limit, got %v", indices(selected))
+	}
+}
+
+func TestLoadBalancedSelectorLiteral(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	candidates := publicParties(4)
+	selector := &LoadBalancedSelector{MaxLoad: 1}
+	if selected := selector.Select(candidates[0], candidates, 2, r); len(selected) != 2 {
+		t.Fatalf("Expected 2 guardians, got %v", indices(selected))
+	}
+	selector.Select(candidates[1], candidates, 3, r)
+	for _, c := range candidates {
+		if selector.Load(c.Index) > 1 {
+			t.Errorf("Party_%d guards %d parties over the limit of 1", c.Index, selector.Load(c.Index))
+		}
+	}
+}
+
+func TestGenerateSetOfNodesBelowThreshold(t *testing.T) {
+	config := common.VotingConfig{
+		Size:          6,
+		Options:       2,
+		Threshold:     2,
+		GuardiansSize: 3,
+	}
+	// every party guards at most one other, so most get fewer than 2 guardians
+	_, _, err := GenerateSetOfNodes(config, 6, NewLoadBalancedSelector(1), curve, rand.New(rand.NewSource(0)))
+	if !errors.Is(err, common.ErrGuardianSetOutOfBounds) {
+		t.Errorf("Expected ErrGuardianSetOutOfBounds, got %v", err)
+	}
+}
@@ -15,6 +15,12 @@ type Polynomial struct {
 	threshold    int
 }
 
+// NewPolynomial rebuilds a polynomial from known coefficients, e.g. when
+// loading a party from disk.
+func NewPolynomial(coefficients []big.Int, curve elliptic.Curve) Polynomial {
+	return Polynomial{coefficients, curve, len(coefficients)}
+}
+
 func (p Polynomial) Coefficients() []big.Int {
 	return p.coefficients
 }
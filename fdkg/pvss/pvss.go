@@ -111,13 +111,13 @@ func CreateShares(drg *rand.Rand, escrow Escrow, pubKeys []common.Point, curve e
 }
 
 func CreateXi(id ShareId, commitments []Commitment, curve elliptic.Curve) common.Point {
-	r := common.PointZero()
+	powers := make([]big.Int, len(commitments))
+	points := make([]common.Point, len(commitments))
 	for j, commit := range commitments {
-		e := new(big.Int).Exp(big.NewInt(int64(id)), big.NewInt(int64(j)), curve.Params().N)
-		r_temp_X, r_temp_Y := curve.ScalarMult(&commit.point.X, &commit.point.Y, e.Bytes())
-		r = common.BigIntToPoint(curve.Add(&r.X, &r.Y, r_temp_X, r_temp_Y))
+		powers[j].Exp(big.NewInt(int64(id)), big.NewInt(int64(j)), curve.Params().N)
+		points[j] = commit.point
 	}
-	return r
+	return utils.MultiScalarMult(powers, points, curve)
 }
 
 func (e *EncryptedShare) Verify(id ShareId, pubKey common.Point, extraGenerator common.Point, commitments []Commitment, curve elliptic.Curve) bool {
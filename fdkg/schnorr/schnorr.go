@@ -0,0 +1,51 @@
+package schnorr
+
+import (
+	"crypto/elliptic"
+	"math/big"
+
+	"github.com/delendum-xyz/private-voting/fdkg/common"
+	"github.com/delendum-xyz/private-voting/fdkg/utils"
+)
+
+// Signature is a Schnorr signature (R, s) with s = k + e*x and e = H(R, P, m).
+type Signature struct {
+	R common.Point
+	S big.Int
+}
+
+// Challenge computes e = H(R, P, m) mod N.
+func Challenge(R common.Point, pubKey common.Point, message []byte, curve elliptic.Curve) big.Int {
+	e := new(big.Int).SetBytes(utils.Keccak256(R.Marshal(curve), pubKey.Marshal(curve), message))
+	return *e.Mod(e, curve.Params().N)
+}
+
+// Sign signs the message with a nonce k from crypto/rand: anyone who can
+// predict k learns the private key from the signature.
+func Sign(privKey big.Int, message []byte, curve elliptic.Curve) Signature {
+	pubKey := common.BigIntToPoint(curve.ScalarBaseMult(privKey.Bytes()))
+	k := utils.RandomBigIntCrypto(curve)
+	R := common.BigIntToPoint(curve.ScalarBaseMult(k.Bytes()))
+	e := Challenge(R, pubKey, message, curve)
+
+	// s = k + e*x
+	s := new(big.Int).Mul(&e, &privKey)
+	s.Add(s, &k)
+	s.Mod(s, curve.Params().N)
+	return Signature{R: R, S: *s}
+}
+
+// Verify checks s*G == R + e*P.
+func Verify(pubKey common.Point, message []byte, sig Signature, curve elliptic.Curve) bool {
+	if !pubKey.IsOnCurve(curve) || !sig.R.IsOnCurve(curve) {
+		return false
+	}
+	if sig.S.Sign() < 0 || sig.S.Cmp(curve.Params().N) >= 0 {
+		return false
+	}
+	e := Challenge(sig.R, pubKey, message, curve)
+	sGX, sGY := curve.ScalarBaseMult(sig.S.Bytes())
+	ePX, ePY := curve.ScalarMult(&pubKey.X, &pubKey.Y, e.Bytes())
+	X, Y := curve.Add(&sig.R.X, &sig.R.Y, ePX, ePY)
+	return X.Cmp(sGX) == 0 && Y.Cmp(sGY) == 0
+}
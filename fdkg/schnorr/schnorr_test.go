@@ -0,0 +1,37 @@
+package schnorr
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/delendum-xyz/private-voting/fdkg/common"
+	"github.com/delendum-xyz/private-voting/fdkg/utils"
+	"github.com/torusresearch/pvss/secp256k1"
+)
+
+const ITERATIONS = 100
+
+var curve = secp256k1.Curve
+
+func TestSignAndVerify(t *testing.T) {
+	for i := 0; i < ITERATIONS; i++ {
+		r := rand.New(rand.NewSource(int64(i)))
+		privKey := utils.RandomBigInt(curve, r)
+		pubKey := common.BigIntToPoint(curve.ScalarBaseMult(privKey.Bytes()))
+
+		message := []byte("guardian acceptance")
+		sig := Sign(privKey, message, curve)
+		if !Verify(pubKey, message, sig, curve) {
+			t.Errorf("[%v] valid signature was rejected", i)
+		}
+		if Verify(pubKey, []byte("other message"), sig, curve) {
+			t.Errorf("[%v] signature verified for a different message", i)
+		}
+
+		otherKey := utils.RandomBigInt(curve, r)
+		otherPubKey := common.BigIntToPoint(curve.ScalarBaseMult(otherKey.Bytes()))
+		if Verify(otherPubKey, message, sig, curve) {
+			t.Errorf("[%v] signature verified under a different public key", i)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package topology
+
+import (
+	"math/rand"
+	"sort"
+
+	"github.com/delendum-xyz/private-voting/fdkg/pki"
+	"github.com/samber/lo"
+)
+
+// Graph is a trust graph over party indices 1..n: Graph[i] lists the parties
+// that party i trusts, i.e. its guardian candidates.
+type Graph map[int][]int
+
+func (g Graph) Neighbours(index int) []int {
+	return g[index]
+}
+
+func (g Graph) Degrees() map[int]int {
+	return lo.MapValues(g, func(neighbours []int, _ int) int { return len(neighbours) })
+}
+
+// Selector picks a party's guardians among its neighbours, topping up with
+// uniformly random guardians when a party has fewer than k neighbours.
+func (g Graph) Selector() pki.GuardianSelector {
+	return pki.TrustGraphSelector{Graph: g, Fallback: pki.UniformSelector{}}
+}
+
+// edges collects an undirected graph as sorted adjacency lists so that the
+// generators consume randomness in a deterministic order.
+type edges map[int]map[int]bool
+
+func newEdges(n int) edges {
+	e := make(edges, n)
+	for i := 1; i <= n; i++ {
+		e[i] = make(map[int]bool)
+	}
+	return e
+}
+
+func (e edges) add(i, j int) {
+	e[i][j] = true
+	e[j][i] = true
+}
+
+func (e edges) remove(i, j int) {
+	delete(e[i], j)
+	delete(e[j], i)
+}
+
+func (e edges) graph() Graph {
+	g := make(Graph, len(e))
+	for i, neighbours := range e {
+		g[i] = lo.Keys(neighbours)
+		sort.Ints(g[i])
+	}
+	return g
+}
+
+// BarabasiAlbert generates a scale-free graph by preferential attachment, as
+// liveness_sim and BarabasiAlbertNetwork.ts do: the first m+1 parties form a
+// clique and every later party trusts m distinct parties picked with
+// probability proportional to their degree. Each party ends up with m
+// outgoing edges.
+func BarabasiAlbert(n, m int, r *rand.Rand) Graph {
+	if m < 1 || m >= n {
+		panic("m must be in [1, n-1]")
+	}
+	g := make(Graph, n)
+	degreeList := make([]int, 0, 2*n*m)
+	for i := 1; i <= m; i++ {
+		for j := 1; j <= m+1; j++ {
+			if i != j {
+				g[i] = append(g[i], j)
+				degreeList = append(degreeList, i, j)
+			}
+		}
+	}
+	for newNode := m + 1; newNode <= n; newNode++ {
+		targets := make(map[int]bool, m)
+		for len(targets) < m {
+			target := degreeList[r.Intn(len(degreeList))]
+			if target != newNode && !targets[target] {
+				targets[target] = true
+				g[newNode] = append(g[newNode], target)
+				degreeList = append(degreeList, newNode, target)
+			}
+		}
+	}
+	return g
+}
+
+// ErdosRenyi generates G(n, p): every pair of parties trusts each other with
+// probability p, independently.
+func ErdosRenyi(n int, p float64, r *rand.Rand) Graph {
+	e := newEdges(n)
+	for i := 1; i <= n; i++ {
+		for j := i + 1; j <= n; j++ {
+			if r.Float64() < p {
+				e.add(i, j)
+			}
+		}
+	}
+	return e.graph()
+}
+
+// ringLattice connects every party to its k/2 nearest parties on each side of
+// a ring.
+func ringLattice(n, k int) edges {
+	if k%2 != 0 || k < 2 || k >= n {
+		panic("k must be even and in [2, n-1]")
+	}
+	e := newEdges(n)
+	for i := 1; i <= n; i++ {
+		for d := 1; d <= k/2; d++ {
+			e.add(i, (i-1+d)%n+1)
+		}
+	}
+	return e
+}
+
+// WattsStrogatz generates a small-world graph: a ring lattice of degree k
+// whose edges are each rewired to a random party with probability beta.
+func WattsStrogatz(n, k int, beta float64, r *rand.Rand) Graph {
+	e := ringLattice(n, k)
+	for d := 1; d <= k/2; d++ {
+		for i := 1; i <= n; i++ {
+			j := (i-1+d)%n + 1
+			if r.Float64() >= beta || len(e[i]) >= n-1 {
+				continue
+			}
+			target := r.Intn(n) + 1
+			for target == i || e[i][target] {
+				target = r.Intn(n) + 1
+			}
+			e.remove(i, j)
+			e.add(i, target)
+		}
+	}
+	return e.graph()
+}
+
+// SmallWorld generates a Newman-Watts small-world trust graph: every party
+// keeps its k local ring neighbours and, for each of them, gains a random
+// long-range trust link with probability p. Unlike WattsStrogatz no local
+// link is ever dropped, so the graph stays connected.
+func SmallWorld(n, k int, p float64, r *rand.Rand) Graph {
+	e := ringLattice(n, k)
+	for d := 1; d <= k/2; d++ {
+		for i := 1; i <= n; i++ {
+			if r.Float64() >= p || len(e[i]) >= n-1 {
+				continue
+			}
+			target := r.Intn(n) + 1
+			for target == i || e[i][target] {
+				target = r.Intn(n) + 1
+			}
+			e.add(i, target)
+		}
+	}
+	return e.graph()
+}
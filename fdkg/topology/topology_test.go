@@ -0,0 +1,132 @@
+package topology
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/delendum-xyz/private-voting/fdkg/common"
+	"github.com/delendum-xyz/private-voting/fdkg/pki"
+	"github.com/samber/lo"
+	"github.com/torusresearch/pvss/secp256k1"
+)
+
+func checkSimple(t *testing.T, name string, g Graph, n int) {
+	if len(g) != n {
+		t.Errorf("%s: expected %d parties, got %d", name, n, len(g))
+	}
+	for i, neighbours := range g {
+		if i < 1 || i > n {
+			t.Errorf("%s: party index %d out of range", name, i)
+		}
+		if lo.Contains(neighbours, i) {
+			t.Errorf("%s: Party_%d trusts itself", name, i)
+		}
+		if len(lo.Uniq(neighbours)) != len(neighbours) {
+			t.Errorf("%s: Party_%d has duplicate neighbours %v", name, i, neighbours)
+		}
+	}
+}
+
+func totalDegree(g Graph) int {
+	return lo.Sum(lo.Values(g.Degrees()))
+}
+
+func TestBarabasiAlbert(t *testing.T) {
+	for _, m := range []int{1, 3, 5} {
+		g := BarabasiAlbert(100, m, rand.New(rand.NewSource(0)))
+		checkSimple(t, "BA", g, 100)
+		for i, d := range g.Degrees() {
+			if d != m {
+				t.Errorf("BA: Party_%d expected out-degree %d, got %d", i, m, d)
+			}
+		}
+	}
+}
+
+func TestErdosRenyi(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	g := ErdosRenyi(30, 1, r)
+	checkSimple(t, "ER", g, 30)
+	if totalDegree(g) != 30*29 {
+		t.Errorf("ER with p=1 should be complete, total degree %d", totalDegree(g))
+	}
+	if totalDegree(ErdosRenyi(30, 0, r)) != 0 {
+		t.Errorf("ER with p=0 should be empty")
+	}
+	g = ErdosRenyi(200, 0.1, r)
+	checkSimple(t, "ER", g, 200)
+	mean := float64(totalDegree(g)) / 200
+	if mean < 15 || mean > 25 {
+		t.Errorf("ER mean degree %v too far from 19.9", mean)
+	}
+}
+
+func TestWattsStrogatz(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	lattice := WattsStrogatz(20, 4, 0, r)
+	checkSimple(t, "WS", lattice, 20)
+	for i, d := range lattice.Degrees() {
+		if d != 4 {
+			t.Errorf("WS with beta=0: Party_%d expected degree 4, got %d", i, d)
+		}
+	}
+	if !lo.Every(lattice.Neighbours(1), []int{2, 3, 19, 20}) {
+		t.Errorf("WS with beta=0: Party_1 expected ring neighbours, got %v", lattice.Neighbours(1))
+	}
+
+	rewired := WattsStrogatz(100, 6, 0.3, r)
+	checkSimple(t, "WS", rewired, 100)
+	if totalDegree(rewired) != 100*6 {
+		t.Errorf("WS rewiring should keep the number of edges, total degree %d", totalDegree(rewired))
+	}
+}
+
+func TestSmallWorld(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	g := SmallWorld(100, 4, 0.2, r)
+	checkSimple(t, "SW", g, 100)
+	for i := 1; i <= 100; i++ {
+		if !lo.Every(g.Neighbours(i), []int{(i % 100) + 1, ((i + 1) % 100) + 1}) {
+			t.Errorf("SW: Party_%d lost a ring neighbour: %v", i, g.Neighbours(i))
+		}
+	}
+	if totalDegree(g) <= 100*4 {
+		t.Errorf("SW should add shortcuts, total degree %d", totalDegree(g))
+	}
+}
+
+func TestGeneratorsAreReproducible(t *testing.T) {
+	generators := map[string]func(r *rand.Rand) Graph{
+		"BA": func(r *rand.Rand) Graph { return BarabasiAlbert(50, 3, r) },
+		"ER": func(r *rand.Rand) Graph { return ErdosRenyi(50, 0.1, r) },
+		"WS": func(r *rand.Rand) Graph { return WattsStrogatz(50, 4, 0.2, r) },
+		"SW": func(r *rand.Rand) Graph { return SmallWorld(50, 4, 0.2, r) },
+	}
+	for name, generate := range generators {
+		if !reflect.DeepEqual(generate(rand.New(rand.NewSource(7))), generate(rand.New(rand.NewSource(7)))) {
+			t.Errorf("%s: graphs generated from the same seed differ", name)
+		}
+	}
+}
+
+func TestGuardiansAreNeighbours(t *testing.T) {
+	config := common.VotingConfig{
+		Size:          30,
+		Options:       2,
+		Threshold:     2,
+		GuardiansSize: 3,
+	}
+	r := rand.New(rand.NewSource(0))
+	g := BarabasiAlbert(config.Size, config.GuardiansSize, r)
+	_, dkgNodes, err := pki.GenerateSetOfNodes(config, 10, g.Selector(), secp256k1.Curve, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, node := range dkgNodes {
+		guardians := lo.Map(node.TrustedParties, func(p pki.PublicParty, _ int) int { return p.Index })
+		if !lo.Every(g.Neighbours(node.Index), guardians) {
+			t.Errorf("Party_%d guardians %v are not its neighbours %v", node.Index, guardians, g.Neighbours(node.Index))
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package utils
+
+import (
+	"crypto/elliptic"
+	"encoding/binary"
+	"math/big"
+	"math/bits"
+	"sync"
+)
+
+// element is a field element in Montgomery form, x*2^256 mod P, as four
+// little-endian 64-bit limbs, always reduced below P.
+type element [4]uint64
+
+func (x *element) isZero() bool {
+	return x[0]|x[1]|x[2]|x[3] == 0
+}
+
+// field is the arithmetic modulo the prime P of a curve y^2 = x^3 + ax + b,
+// for P of at most 256 bits. A product costs a few dozen word
+// multiplications instead of a big.Int multiplication and division.
+type field struct {
+	P *big.Int
+	p element
+	// pInv is -1/P mod 2^64
+	pInv uint64
+	// rr is 2^512 mod P, to enter the Montgomery form
+	rr element
+	// one is 1 in Montgomery form
+	one element
+	// a is the curve coefficient, nil when it is zero
+	a *element
+}
+
+var fields sync.Map
+
+// fieldOf is the field of the curve, or nil when its prime is larger than
+// 256 bits.
+func fieldOf(curve elliptic.Curve) *field {
+	if f, found := fields.Load(curve); found {
+		return f.(*field)
+	}
+	params := curve.Params()
+	var f *field
+	if params.P.BitLen() <= 256 {
+		f = &field{P: params.P, p: limbs(params.P)}
+		// Newton's iteration doubles the correct low bits of 1/P each step
+		inv := uint64(1)
+		for i := 0; i < 6; i++ {
+			inv *= 2 - f.p[0]*inv
+		}
+		f.pInv = -inv
+		f.rr = limbs(new(big.Int).Mod(new(big.Int).Lsh(big.NewInt(1), 512), f.P))
+		f.fromBig(&f.one, big.NewInt(1))
+		// elliptic.CurveParams has no a: recover it from the generator as
+		// a = (Gy^2 - Gx^3 - B) / Gx
+		a := new(big.Int).Mul(params.Gy, params.Gy)
+		a.Sub(a, new(big.Int).Exp(params.Gx, big.NewInt(3), f.P))
+		a.Sub(a, params.B)
+		a.Mul(a, new(big.Int).ModInverse(params.Gx, f.P))
+		if a.Mod(a, f.P); a.Sign() != 0 {
+			f.a = new(element)
+			f.fromBig(f.a, a)
+		}
+	}
+	stored, _ := fields.LoadOrStore(curve, f)
+	return stored.(*field)
+}
+
+func limbs(x *big.Int) element {
+	var buf [32]byte
+	x.FillBytes(buf[:])
+	var z element
+	for i := range z {
+		z[i] = binary.BigEndian.Uint64(buf[32-8*(i+1):])
+	}
+	return z
+}
+
+// fromBig sets z to x, reduced modulo P, in Montgomery form.
+func (f *field) fromBig(z *element, x *big.Int) {
+	if x.Sign() < 0 || x.Cmp(f.P) >= 0 {
+		x = new(big.Int).Mod(x, f.P)
+	}
+	*z = limbs(x)
+	f.mul(z, z, &f.rr)
+}
+
+func (f *field) toBig(x *element) *big.Int {
+	one := element{1}
+	var z element
+	f.mul(&z, x, &one)
+	var buf [32]byte
+	for i := range z {
+		binary.BigEndian.PutUint64(buf[32-8*(i+1):], z[i])
+	}
+	return new(big.Int).SetBytes(buf[:])
+}
+
+// mul sets z = x*y / 2^256 mod P, by Montgomery multiplication with the
+// operand scanning of Koç, Acar and Kaliski. z may alias x or y.
+func (f *field) mul(z, x, y *element) {
+	x0, x1, x2, x3 := x[0], x[1], x[2], x[3]
+	p0, p1, p2, p3 := f.p[0], f.p[1], f.p[2], f.p[3]
+	var t0, t1, t2, t3, t4 uint64
+	for i := 0; i < 4; i++ {
+		// t += x*y[i], then t = (t + m*P) / 2^64 for the m making it divisible
+		var c, t5, carry uint64
+		c, t0 = madd(x0, y[i], t0, 0)
+		c, t1 = madd(x1, y[i], t1, c)
+		c, t2 = madd(x2, y[i], t2, c)
+		c, t3 = madd(x3, y[i], t3, c)
+		t4, t5 = bits.Add64(t4, c, 0)
+
+		m := t0 * f.pInv
+		c, _ = madd(m, p0, t0, 0)
+		c, t0 = madd(m, p1, t1, c)
+		c, t1 = madd(m, p2, t2, c)
+		c, t2 = madd(m, p3, t3, c)
+		t3, carry = bits.Add64(t4, c, 0)
+		t4 = t5 + carry
+	}
+	f.normalize(z, t4, element{t0, t1, t2, t3})
+}
+
+// normalize sets z to the 257-bit high:x minus P if that is not negative,
+// else to x.
+func (f *field) normalize(z *element, high uint64, x element) {
+	var d element
+	var borrow uint64
+	for i := range d {
+		d[i], borrow = bits.Sub64(x[i], f.p[i], borrow)
+	}
+	if high != 0 || borrow == 0 {
+		*z = d
+	} else {
+		*z = x
+	}
+}
+
+// madd is the 128-bit a*b + c + d as hi, lo.
+func madd(a, b, c, d uint64) (hi, lo uint64) {
+	hi, lo = bits.Mul64(a, b)
+	var carry uint64
+	lo, carry = bits.Add64(lo, c, 0)
+	hi += carry
+	lo, carry = bits.Add64(lo, d, 0)
+	hi += carry
+	return hi, lo
+}
+
+func (f *field) add(z, x, y *element) {
+	var s element
+	var carry uint64
+	for i := range s {
+		s[i], carry = bits.Add64(x[i], y[i], carry)
+	}
+	f.normalize(z, carry, s)
+}
+
+func (f *field) sub(z, x, y *element) {
+	var d element
+	var borrow uint64
+	for i := range d {
+		d[i], borrow = bits.Sub64(x[i], y[i], borrow)
+	}
+	if borrow != 0 {
+		var carry uint64
+		for i := range d {
+			d[i], carry = bits.Add64(d[i], f.p[i], carry)
+		}
+	}
+	*z = d
+}
+
+func (f *field) neg(z, x *element) {
+	f.sub(z, &element{}, x)
+}
+
+// inverse sets z to 1/x, x being non-zero.
+func (f *field) inverse(z, x *element) {
+	f.fromBig(z, new(big.Int).ModInverse(f.toBig(x), f.P))
+}
@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"crypto/elliptic"
+	"math/big"
+
+	"github.com/delendum-xyz/private-voting/fdkg/common"
+)
+
+// FixedBase holds the multiples d*2^(wj)*P of a point P used over and over,
+// such as G or the voting key, for a window of w bits. A multiplication by P
+// is then one addition per window of the scalar, in Jacobian coordinates,
+// and a single inversion: no doublings. The table has 2^w - 1 points per
+// window and costs about as many additions to build, so a wider window
+// only pays for a point multiplied very often, such as G. On a curve whose
+// prime is larger than 256 bits it falls back to ScalarMult.
+type FixedBase struct {
+	Point  common.Point
+	curve  elliptic.Curve
+	field  *field
+	window int
+	// tables[j][d-1] = d*2^(wj)*P
+	tables [][]affinePoint
+}
+
+func NewFixedBase(p common.Point, window int, curve elliptic.Curve) *FixedBase {
+	fb := &FixedBase{Point: p, curve: curve, field: fieldOf(curve), window: window}
+	f := fb.field
+	if f == nil {
+		return fb
+	}
+	windows := (curve.Params().N.BitLen() + window - 1) / window
+	// the bases 2^(wj)*P of the windows, then their multiples, each brought
+	// to affine coordinates together
+	bases := make([]jacobian, windows)
+	q := f.fromPoint(p)
+	f.setAffine(&bases[0], &q)
+	for j := 1; j < windows; j++ {
+		bases[j] = bases[j-1]
+		for b := 0; b < window; b++ {
+			f.double(&bases[j])
+		}
+	}
+	size := 1<<window - 1
+	multiples := make([]jacobian, 0, windows*size)
+	for _, base := range f.affineAll(bases) {
+		next := jacobian{}
+		for d := 0; d < size; d++ {
+			f.addAffine(&next, &base)
+			multiples = append(multiples, next)
+		}
+	}
+	points := f.affineAll(multiples)
+	fb.tables = make([][]affinePoint, windows)
+	for j := range fb.tables {
+		fb.tables[j] = points[j*size : (j+1)*size]
+	}
+	return fb
+}
+
+// scalarMultCounter is a curve that counts scalar multiplications, like
+// cost.CountingCurve. A multiplication from a table only adds, so Mult reports
+// it to the curve itself.
+type scalarMultCounter interface {
+	CountScalarMult()
+}
+
+// Mult computes k*P.
+func (fb *FixedBase) Mult(k *big.Int) common.Point {
+	if fb.field == nil {
+		k = new(big.Int).Mod(k, fb.curve.Params().N)
+		return common.BigIntToPoint(fb.curve.ScalarMult(&fb.Point.X, &fb.Point.Y, k.Bytes()))
+	}
+	if counter, ok := fb.curve.(scalarMultCounter); ok {
+		counter.CountScalarMult()
+	}
+	k = new(big.Int).Mod(k, fb.curve.Params().N)
+	var acc jacobian
+	for j := range fb.tables {
+		if d := digit(k, j, fb.window); d != 0 {
+			fb.field.addAffine(&acc, &fb.tables[j][d-1])
+		}
+	}
+	return fb.field.affine(&acc)
+}
@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"fmt"
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/delendum-xyz/private-voting/fdkg/common"
+)
+
+func TestFixedBase(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	k := RandomBigInt(curve, r)
+	P := common.BigIntToPoint(curve.ScalarBaseMult(k.Bytes()))
+
+	scalars := []*big.Int{big.NewInt(0), big.NewInt(1), big.NewInt(15), big.NewInt(16), new(big.Int).Sub(curve.Params().N, big.NewInt(1)), new(big.Int).Add(curve.Params().N, big.NewInt(5))}
+	for i := 0; i < 10; i++ {
+		k := RandomBigInt(curve, r)
+		scalars = append(scalars, &k)
+	}
+	for _, window := range []int{4, 8} {
+		table := NewFixedBase(P, window, curve)
+		for _, k := range scalars {
+			reduced := new(big.Int).Mod(k, curve.Params().N)
+			expected := common.BigIntToPoint(curve.ScalarMult(&P.X, &P.Y, reduced.Bytes()))
+			if result := table.Mult(k); !result.Equal(expected) {
+				t.Errorf("Mult(%v) with a %d-bit window differs from ScalarMult", k, window)
+			}
+		}
+	}
+}
+
+func BenchmarkFixedBase(b *testing.B) {
+	r := rand.New(rand.NewSource(0))
+	k := RandomBigInt(curve, r)
+	G := common.BigIntToPoint(curve.Params().Gx, curve.Params().Gy)
+	for _, window := range []int{4, 8} {
+		b.Run(fmt.Sprintf("NewFixedBase/w=%d", window), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				NewFixedBase(G, window, curve)
+			}
+		})
+		table := NewFixedBase(G, window, curve)
+		b.Run(fmt.Sprintf("Mult/w=%d", window), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				table.Mult(&k)
+			}
+		})
+	}
+	b.Run("ScalarBaseMult", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			curve.ScalarBaseMult(k.Bytes())
+		}
+	})
+	b.Run("ScalarMult", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			curve.ScalarMult(&G.X, &G.Y, k.Bytes())
+		}
+	})
+}
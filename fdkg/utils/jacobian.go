@@ -0,0 +1,201 @@
+package utils
+
+import (
+	"github.com/delendum-xyz/private-voting/fdkg/common"
+)
+
+// affinePoint is a point in the field's representation.
+type affinePoint struct {
+	x, y     element
+	infinity bool
+}
+
+// jacobian is the point (x/z^2, y/z^3); z = 0 is the point at infinity, which
+// the zero value is. Going through curve.Add costs an inversion per addition;
+// sums kept in Jacobian coordinates pay a single inversion at the end.
+type jacobian struct {
+	x, y, z element
+}
+
+func (f *field) fromPoint(p common.Point) affinePoint {
+	if p.X.Sign() == 0 && p.Y.Sign() == 0 {
+		return affinePoint{infinity: true}
+	}
+	var q affinePoint
+	f.fromBig(&q.x, &p.X)
+	f.fromBig(&q.y, &p.Y)
+	return q
+}
+
+func (f *field) setAffine(p *jacobian, q *affinePoint) {
+	if q.infinity {
+		*p = jacobian{}
+		return
+	}
+	p.x, p.y = q.x, q.y
+	p.z = f.one
+}
+
+// double doubles p in place.
+func (f *field) double(p *jacobian) {
+	if p.z.isZero() || p.y.isZero() {
+		*p = jacobian{}
+		return
+	}
+	var xx, yy, yyyy, s, m, t element
+	f.mul(&xx, &p.x, &p.x)
+	f.mul(&yy, &p.y, &p.y)
+	f.mul(&yyyy, &yy, &yy)
+	// S = 4*X*YY, M = 3*XX + a*Z^4
+	f.mul(&s, &p.x, &yy)
+	f.add(&s, &s, &s)
+	f.add(&s, &s, &s)
+	f.add(&m, &xx, &xx)
+	f.add(&m, &m, &xx)
+	if f.a != nil {
+		f.mul(&t, &p.z, &p.z)
+		f.mul(&t, &t, &t)
+		f.mul(&t, &t, f.a)
+		f.add(&m, &m, &t)
+	}
+	// Z3 = 2*Y*Z, X3 = M^2 - 2*S, Y3 = M*(S - X3) - 8*YYYY
+	f.mul(&p.z, &p.y, &p.z)
+	f.add(&p.z, &p.z, &p.z)
+	f.mul(&p.x, &m, &m)
+	f.sub(&p.x, &p.x, &s)
+	f.sub(&p.x, &p.x, &s)
+	f.sub(&t, &s, &p.x)
+	f.mul(&p.y, &m, &t)
+	f.add(&yyyy, &yyyy, &yyyy)
+	f.add(&yyyy, &yyyy, &yyyy)
+	f.add(&yyyy, &yyyy, &yyyy)
+	f.sub(&p.y, &p.y, &yyyy)
+}
+
+// addAffine adds q to p in place.
+func (f *field) addAffine(p *jacobian, q *affinePoint) {
+	if q.infinity {
+		return
+	}
+	if p.z.isZero() {
+		f.setAffine(p, q)
+		return
+	}
+	var z1z1, u2, s2 element
+	f.mul(&z1z1, &p.z, &p.z)
+	f.mul(&u2, &q.x, &z1z1)
+	f.mul(&s2, &p.z, &z1z1)
+	f.mul(&s2, &q.y, &s2)
+	u1, s1, z := p.x, p.y, p.z
+	f.finishAdd(p, &u1, &s1, &u2, &s2, &z)
+}
+
+// addJacobian adds q to p in place.
+func (f *field) addJacobian(p, q *jacobian) {
+	if q.z.isZero() {
+		return
+	}
+	if p.z.isZero() {
+		*p = *q
+		return
+	}
+	var z1z1, z2z2, u1, u2, s1, s2, z element
+	f.mul(&z1z1, &p.z, &p.z)
+	f.mul(&z2z2, &q.z, &q.z)
+	f.mul(&u1, &p.x, &z2z2)
+	f.mul(&u2, &q.x, &z1z1)
+	f.mul(&s1, &q.z, &z2z2)
+	f.mul(&s1, &p.y, &s1)
+	f.mul(&s2, &p.z, &z1z1)
+	f.mul(&s2, &q.y, &s2)
+	f.mul(&z, &p.z, &q.z)
+	f.finishAdd(p, &u1, &s1, &u2, &s2, &z)
+}
+
+// finishAdd sets p to the sum of (u1, s1) and (u2, s2) brought to the same
+// z, z1z2 being the product of the z of the terms. When they are the same
+// point, p is one of them and is doubled.
+func (f *field) finishAdd(p *jacobian, u1, s1, u2, s2, z1z2 *element) {
+	var h, r element
+	f.sub(&h, u2, u1)
+	f.sub(&r, s2, s1)
+	if h.isZero() {
+		if r.isZero() {
+			f.double(p)
+		} else {
+			*p = jacobian{}
+		}
+		return
+	}
+	var hh, hhh, v, t element
+	f.mul(&hh, &h, &h)
+	f.mul(&hhh, &h, &hh)
+	f.mul(&v, u1, &hh)
+	// X3 = r^2 - HHH - 2*V, Y3 = r*(V - X3) - S1*HHH, Z3 = Z1*Z2*H
+	f.mul(&p.x, &r, &r)
+	f.sub(&p.x, &p.x, &hhh)
+	f.sub(&p.x, &p.x, &v)
+	f.sub(&p.x, &p.x, &v)
+	f.sub(&t, &v, &p.x)
+	f.mul(&p.y, &r, &t)
+	f.mul(&t, s1, &hhh)
+	f.sub(&p.y, &p.y, &t)
+	f.mul(&p.z, z1z2, &h)
+}
+
+func (f *field) toPoint(p *affinePoint) common.Point {
+	if p.infinity {
+		return common.PointZero()
+	}
+	return common.BigIntToPoint(f.toBig(&p.x), f.toBig(&p.y))
+}
+
+func (f *field) affine(p *jacobian) common.Point {
+	if p.z.isZero() {
+		return common.PointZero()
+	}
+	var zInv element
+	f.inverse(&zInv, &p.z)
+	q := f.scale(p, &zInv)
+	return f.toPoint(&q)
+}
+
+// scale is p in affine coordinates given the inverse of its z.
+func (f *field) scale(p *jacobian, zInv *element) affinePoint {
+	var zInv2, zInv3 element
+	f.mul(&zInv2, zInv, zInv)
+	f.mul(&zInv3, &zInv2, zInv)
+	var q affinePoint
+	f.mul(&q.x, &p.x, &zInv2)
+	f.mul(&q.y, &p.y, &zInv3)
+	return q
+}
+
+// affineAll converts the points to affine coordinates with a single
+// inversion, by Montgomery's trick.
+func (f *field) affineAll(points []jacobian) []affinePoint {
+	// prefix[i] is the product of the z of the finite points before i
+	prefix := make([]element, len(points))
+	product := f.one
+	for i := range points {
+		prefix[i] = product
+		if !points[i].z.isZero() {
+			f.mul(&product, &product, &points[i].z)
+		}
+	}
+	var inverse element
+	f.inverse(&inverse, &product)
+	result := make([]affinePoint, len(points))
+	for i := len(points) - 1; i >= 0; i-- {
+		if points[i].z.isZero() {
+			result[i] = affinePoint{infinity: true}
+			continue
+		}
+		// inverse is now 1 over the product of the z up to and including i
+		var zInv element
+		f.mul(&zInv, &inverse, &prefix[i])
+		result[i] = f.scale(&points[i], &zInv)
+		f.mul(&inverse, &inverse, &points[i].z)
+	}
+	return result
+}
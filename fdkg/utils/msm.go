@@ -0,0 +1,162 @@
+package utils
+
+import (
+	"crypto/elliptic"
+	"math/big"
+
+	"github.com/delendum-xyz/private-voting/fdkg/common"
+)
+
+// strausWindow is the width of the non-adjacent form of the scalars in
+// Straus' method.
+const strausWindow = 5
+
+// pippengerThreshold is the number of terms from which Pippenger's method
+// beats Straus'.
+const pippengerThreshold = 128
+
+// MultiScalarMult computes the sum of scalars[i]*points[i], with Straus'
+// method for few terms and Pippenger's for many. Both sum in Jacobian
+// coordinates and pay a single inversion, where the loop of ScalarMult and
+// Add pays one per addition. A single term, or a curve whose prime is
+// larger than 256 bits, is left to the curve's own ScalarMult.
+func MultiScalarMult(scalars []big.Int, points []common.Point, curve elliptic.Curve) common.Point {
+	if len(scalars) != len(points) {
+		panic("scalars and points differ in length")
+	}
+	N := curve.Params().N
+	reduced := make([]*big.Int, len(scalars))
+	bits := 0
+	for i := range scalars {
+		reduced[i] = new(big.Int).Mod(&scalars[i], N)
+		if reduced[i].BitLen() > bits {
+			bits = reduced[i].BitLen()
+		}
+	}
+	f := fieldOf(curve)
+	switch {
+	case len(points) < 2 || f == nil:
+		x, y := new(big.Int), new(big.Int)
+		for i, p := range points {
+			px, py := curve.ScalarMult(&p.X, &p.Y, reduced[i].Bytes())
+			x, y = curve.Add(x, y, px, py)
+		}
+		return common.BigIntToPoint(x, y)
+	case len(points) >= pippengerThreshold:
+		return pippenger(reduced, points, bits, f)
+	}
+	return straus(reduced, points, bits, f)
+}
+
+// straus shares the doublings among all the terms, and each term adds one
+// precomputed odd multiple of its point, or its negation, per non-zero digit
+// of its scalar in width-w non-adjacent form, about one every w+1 bits. So n
+// terms cost one doubling plus n/6 additions per bit.
+func straus(reduced []*big.Int, points []common.Point, bits int, f *field) common.Point {
+	// the odd multiples d*points[i] for d in [1, 2^(w-1)) from offsets[i]
+	// on, brought to affine coordinates together
+	const odd = 1 << (strausWindow - 2)
+	multiples := make([]jacobian, 0, len(points)*odd)
+	digits := make([][]int8, len(points))
+	for i, p := range points {
+		digits[i] = naf(reduced[i], strausWindow)
+		q := f.fromPoint(p)
+		var next, double jacobian
+		f.setAffine(&next, &q)
+		f.setAffine(&double, &q)
+		f.double(&double)
+		for d := 0; d < odd; d++ {
+			multiples = append(multiples, next)
+			f.addJacobian(&next, &double)
+		}
+	}
+	tables := f.affineAll(multiples)
+
+	var acc jacobian
+	for bit := bits; bit >= 0; bit-- {
+		f.double(&acc)
+		for i := range digits {
+			if bit >= len(digits[i]) || digits[i][bit] == 0 {
+				continue
+			}
+			d := int(digits[i][bit])
+			if d > 0 {
+				f.addAffine(&acc, &tables[i*odd+d/2])
+				continue
+			}
+			q := tables[i*odd-d/2]
+			f.neg(&q.y, &q.y)
+			f.addAffine(&acc, &q)
+		}
+	}
+	return f.affine(&acc)
+}
+
+// naf is the width-w non-adjacent form of k: the digits d[i], zero or odd
+// and below 2^(w-1) in absolute value, of k = sum of d[i]*2^i, with at most
+// one non-zero digit in any w consecutive ones.
+func naf(k *big.Int, w uint) []int8 {
+	k = new(big.Int).Set(k)
+	digits := make([]int8, k.BitLen()+1)
+	mask := big.NewInt(1<<w - 1)
+	low := new(big.Int)
+	for i := 0; k.Sign() > 0; i++ {
+		if k.Bit(0) == 1 {
+			d := low.And(k, mask).Int64()
+			if d >= 1<<(w-1) {
+				d -= 1 << w
+			}
+			digits[i] = int8(d)
+			k.Sub(k, low.SetInt64(d))
+		}
+		k.Rsh(k, 1)
+	}
+	return digits
+}
+
+// pippenger sorts the points of each window into buckets by their digit,
+// sums every bucket once and weights the bucket sums with a running sum, so
+// a window costs n + 2^(c+1) additions whatever the digits. The window width
+// c grows with log n.
+func pippenger(reduced []*big.Int, points []common.Point, bits int, f *field) common.Point {
+	c := 4
+	if l := big.NewInt(int64(len(points))).BitLen() - 2; l > c {
+		c = l
+	}
+	affine := make([]affinePoint, len(points))
+	for i, p := range points {
+		affine[i] = f.fromPoint(p)
+	}
+	buckets := make([]jacobian, 1<<c)
+	var acc jacobian
+	for window := (bits+c-1)/c - 1; window >= 0; window-- {
+		for j := 0; j < c; j++ {
+			f.double(&acc)
+		}
+		for d := range buckets {
+			buckets[d] = jacobian{}
+		}
+		for i, k := range reduced {
+			if d := digit(k, window, c); d != 0 {
+				f.addAffine(&buckets[d], &affine[i])
+			}
+		}
+		// sum of d*buckets[d] = sum over d of the running sum of buckets[d..]
+		var running, sum jacobian
+		for d := len(buckets) - 1; d > 0; d-- {
+			f.addJacobian(&running, &buckets[d])
+			f.addJacobian(&sum, &running)
+		}
+		f.addJacobian(&acc, &sum)
+	}
+	return f.affine(&acc)
+}
+
+// digit is the window-th w-bit digit of k.
+func digit(k *big.Int, window, w int) int {
+	d := 0
+	for j := w - 1; j >= 0; j-- {
+		d = d<<1 | int(k.Bit(window*w+j))
+	}
+	return d
+}
@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"crypto/elliptic"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/delendum-xyz/private-voting/fdkg/common"
+	"github.com/torusresearch/pvss/secp256k1"
+)
+
+var curve = secp256k1.Curve
+
+func naiveMultiScalarMult(scalars []big.Int, points []common.Point, curve elliptic.Curve) common.Point {
+	result := common.PointZero()
+	for i := range points {
+		k := new(big.Int).Mod(&scalars[i], curve.Params().N)
+		X, Y := curve.ScalarMult(&points[i].X, &points[i].Y, k.Bytes())
+		result = common.BigIntToPoint(curve.Add(&result.X, &result.Y, X, Y))
+	}
+	return result
+}
+
+func randomTerms(n int, curve elliptic.Curve, r *rand.Rand) ([]big.Int, []common.Point) {
+	scalars := make([]big.Int, n)
+	points := make([]common.Point, n)
+	for i := range points {
+		scalars[i] = RandomBigInt(curve, r)
+		k := RandomBigInt(curve, r)
+		points[i] = common.BigIntToPoint(curve.ScalarBaseMult(k.Bytes()))
+	}
+	return scalars, points
+}
+
+func TestMultiScalarMult(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	// P-256 has a = -3 and P-521 is left to ScalarMult
+	for _, curve := range []elliptic.Curve{curve, elliptic.P256(), elliptic.P521()} {
+		for _, n := range []int{0, 1, 2, 7, pippengerThreshold - 1, pippengerThreshold, 300} {
+			scalars, points := randomTerms(n, curve, r)
+			if n > 3 {
+				// zero, small, unreduced scalars, the point at infinity and a repeated point
+				scalars[0].SetInt64(0)
+				scalars[1].SetInt64(3)
+				scalars[2].Add(&scalars[2], curve.Params().N)
+				points[3] = common.PointZero()
+				points[n-1] = points[n-2]
+			}
+			expected := naiveMultiScalarMult(scalars, points, curve)
+			if result := MultiScalarMult(scalars, points, curve); !result.Equal(expected) {
+				t.Errorf("MultiScalarMult of %d terms on %s differs from the naive sum", n, curve.Params().Name)
+			}
+		}
+	}
+}
+
+func BenchmarkMultiScalarMult(b *testing.B) {
+	r := rand.New(rand.NewSource(0))
+	for _, n := range []int{3, 20, 200} {
+		scalars, points := randomTerms(n, curve, r)
+		b.Run(fmt.Sprintf("n=%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				MultiScalarMult(scalars, points, curve)
+			}
+		})
+		b.Run(fmt.Sprintf("naive/n=%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				naiveMultiScalarMult(scalars, points, curve)
+			}
+		})
+	}
+}
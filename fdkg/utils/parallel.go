@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// Workers is the number of goroutines ParallelMap runs on, by default one per
+// CPU. With 1 everything runs on the caller's goroutine.
+var Workers = runtime.NumCPU()
+
+// ParallelMap is Map on Workers goroutines. The results keep the order of
+// arr, so they do not depend on the number of workers as long as f only
+// reads shared state.
+func ParallelMap[T, U any](arr []T, f func(T) U) []U {
+	result := make([]U, len(arr))
+	workers := Workers
+	if workers > len(arr) {
+		workers = len(arr)
+	}
+	if workers <= 1 {
+		for i, v := range arr {
+			result[i] = f(v)
+		}
+		return result
+	}
+	var next int64 = -1
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := int(atomic.AddInt64(&next, 1)); i < len(arr); i = int(atomic.AddInt64(&next, 1)) {
+				result[i] = f(arr[i])
+			}
+		}()
+	}
+	wg.Wait()
+	return result
+}
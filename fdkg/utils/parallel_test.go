@@ -0,0 +1,23 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/samber/lo"
+)
+
+func TestParallelMap(t *testing.T) {
+	defer func(workers int) { Workers = workers }(Workers)
+	items := lo.Range(100)
+	for _, workers := range []int{1, 3, 8, 200} {
+		Workers = workers
+		result := ParallelMap(items, func(i int) int { return i * i })
+		if !reflect.DeepEqual(result, Map(items, func(i int) int { return i * i })) {
+			t.Errorf("ParallelMap on %d workers is out of order", workers)
+		}
+		if result := ParallelMap([]int{}, func(i int) int { return i }); len(result) != 0 {
+			t.Errorf("ParallelMap of nothing returned %v", result)
+		}
+	}
+}